@@ -0,0 +1,100 @@
+/*
+Copyright 2019 Konstantin Vasilev (burmuley@gmail.com)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import "sort"
+
+// Methods of one API group available with a particular access token type
+type clientGroup struct {
+	Prefix  string
+	Methods []IMethod
+}
+
+// API client restricted to methods available with a particular access token type
+type tokenClient struct {
+	TokenType string
+	Groups    []clientGroup
+}
+
+// Data structure passed to the clients and fakes templates
+type clientsData struct {
+	Imports map[string]struct{}
+	Groups  []clientGroup // all methods grouped by API name prefix
+	Clients []tokenClient
+}
+
+// buildClients: groups `methods` by API name prefix, and by token type and API name prefix for clients.
+// Groups without methods available for a token type are omitted from the client.
+func buildClients(methods []IMethod) clientsData {
+	data := clientsData{Imports: make(map[string]struct{}), Groups: groupMethods(methods)}
+
+	for _, m := range methods {
+		data.Imports["context"] = struct{}{}
+
+		// method signatures are rendered in the clients file, so it needs the same imports
+		if checkMImports(m.GetParameters(), "objects.") || checkMImports(m.GetResponses(), "objects.") {
+			data.Imports[objectsImportPath] = struct{}{}
+		}
+
+		if checkMImports(m.GetParameters(), "json.Number") {
+			data.Imports["encoding/json"] = struct{}{}
+		}
+
+		if checkMImports(m.GetResponses(), "responses.") {
+			data.Imports[responsesImportPath] = struct{}{}
+		}
+	}
+
+	for _, t := range clientTokenTypes {
+		allowed := make([]IMethod, 0)
+
+		for _, m := range methods {
+			if m.IsAllowedFor(t) {
+				allowed = append(allowed, m)
+			}
+		}
+
+		data.Clients = append(data.Clients, tokenClient{TokenType: t, Groups: groupMethods(allowed)})
+	}
+
+	return data
+}
+
+// groupMethods: groups `methods` by API name prefix sorting groups by prefix
+func groupMethods(methods []IMethod) []clientGroup {
+	groups := make(map[string][]IMethod)
+
+	for _, m := range methods {
+		prefix := getApiNamePrefix(m.GetName())
+		groups[prefix] = append(groups[prefix], m)
+	}
+
+	prefixes := make([]string, 0, len(groups))
+
+	for k := range groups {
+		prefixes = append(prefixes, k)
+	}
+
+	sort.Strings(prefixes)
+
+	result := make([]clientGroup, 0, len(prefixes))
+
+	for _, p := range prefixes {
+		result = append(result, clientGroup{Prefix: p, Methods: groups[p]})
+	}
+
+	return result
+}
@@ -0,0 +1,111 @@
+/*
+Copyright 2019 Konstantin Vasilev (burmuley@gmail.com)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_buildClients(t *testing.T) {
+	methods := []IMethod{
+		schemaMethod{Name: "account.getInfo", AccessTokens: []string{"user"}},
+		schemaMethod{Name: "users.get", AccessTokens: []string{"user", "group", "service"}},
+		schemaMethod{Name: "utils.getServerTime", AccessTokens: []string{"open"}},
+		schemaMethod{Name: "apps.get"},
+	}
+
+	tests := []struct {
+		name      string
+		tokenType string
+		want      map[string][]string
+	}{
+		{
+			"TestUserClient",
+			tokenTypeUser,
+			map[string][]string{"account": {"account.getInfo"}, "users": {"users.get"}, "utils": {"utils.getServerTime"}},
+		},
+		{
+			"TestGroupClient",
+			tokenTypeGroup,
+			map[string][]string{"users": {"users.get"}, "utils": {"utils.getServerTime"}},
+		},
+	}
+
+	clients := buildClients(methods).Clients
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := make(map[string][]string)
+
+			for _, c := range clients {
+				if c.TokenType != tt.tokenType {
+					continue
+				}
+
+				for _, g := range c.Groups {
+					for _, m := range g.Methods {
+						got[g.Prefix] = append(got[g.Prefix], m.GetName())
+					}
+				}
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("buildClients() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_groupMethods(t *testing.T) {
+	tests := []struct {
+		name    string
+		methods []IMethod
+		want    []string
+	}{
+		{"TestEmpty", []IMethod{}, []string{}},
+		{
+			"TestSortedPrefixes",
+			[]IMethod{
+				schemaMethod{Name: "users.get"},
+				schemaMethod{Name: "apps.get"},
+				schemaMethod{Name: "users.search"},
+			},
+			[]string{"apps: apps.get", "users: users.get, users.search"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := make([]string, 0)
+
+			for _, g := range groupMethods(tt.methods) {
+				names := make([]string, 0, len(g.Methods))
+
+				for _, m := range g.Methods {
+					names = append(names, m.GetName())
+				}
+
+				got = append(got, g.Prefix+": "+strings.Join(names, ", "))
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("groupMethods() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
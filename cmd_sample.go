@@ -0,0 +1,103 @@
+/*
+Copyright 2019 Konstantin Vasilev (burmuley@gmail.com)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Usage of the `sample` subcommand
+const sampleUsage = "usage: go-vkapi-gen sample [-seed N] [-minimal] <definition, definition reference or method name>"
+
+// runSample: `sample` subcommand printing to `out` a sample JSON of a definition from objects or responses schema
+// or of a method response. Schema files locations are taken from `vkSchemaFiles`.
+func runSample(args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("sample", flag.ContinueOnError)
+	seed := fs.Int64("seed", 1, "random seed, the same seed produces the same sample")
+	minimal := fs.Bool("minimal", false, "include only required object properties")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if fs.NArg() != 1 {
+		return fmt.Errorf(sampleUsage)
+	}
+
+	methods := &schemaMethods{}
+
+	for _, v := range []struct {
+		fName string
+		sObj  IGenerator
+	}{
+		{"VK_API_SCHEMA_OBJECTS", &objectsSchema{}},
+		{"VK_API_SCHEMA_RESPONSES", &responsesSchema{}},
+		{"VK_API_SCHEMA_METHODS", methods},
+	} {
+		if err := v.sObj.Parse(vkSchemaFiles[v.fName]); err != nil {
+			return err
+		}
+	}
+
+	name := fs.Arg(0)
+	ref := findSampleDefinition(name, methods.Methods)
+
+	if len(ref) == 0 {
+		return fmt.Errorf("definition or method `%s` not found", name)
+	}
+
+	b, err := json.MarshalIndent(newSampler(*seed, *minimal).value("", &schemaJSONProperty{Ref: ref}), "", "  ")
+
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintln(out, string(b))
+
+	return err
+}
+
+// findSampleDefinition: returns reference to definition `name` looking it up among responses, objects
+// and methods (returning the method response). A reference (`objects.json#/definitions/<name>`) selects
+// the schema explicitly when an object and a response have the same name.
+func findSampleDefinition(name string, methods []schemaMethod) string {
+	if strings.Contains(name, "#") {
+		if resolveRef(name) == nil {
+			return ""
+		}
+
+		return qualifyRef(name)
+	}
+
+	for _, ref := range []string{responsesRefPrefix + name, objectsRefPrefix + name} {
+		if resolveRef(ref) != nil {
+			return ref
+		}
+	}
+
+	for _, m := range methods {
+		if m.Name == name && m.Responses.Response != nil && resolveRef(m.Responses.Response.Ref) != nil {
+			return qualifyRef(m.Responses.Response.Ref)
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,121 @@
+/*
+Copyright 2019 Konstantin Vasilev (burmuley@gmail.com)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_runSample(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sample")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"VK_API_SCHEMA_OBJECTS": `{"definitions": {"users_user": {"type": "object", "required": ["id"], "properties": {
+			"id": {"type": "integer"}, "first_name": {"type": "string"}}},
+			"base_ok_response": {"type": "object", "properties": {"ok": {"type": "integer"}}}}}`,
+		"VK_API_SCHEMA_RESPONSES": `{"definitions": {"users_get_response": {"type": "object", "properties": {
+			"response": {"type": "array", "items": {"$ref": "objects.json#/definitions/users_user"}}}},
+			"base_ok_response": {"type": "object", "properties": {
+			"response": {"$ref": "objects.json#/definitions/base_ok_response"}}}}}`,
+		"VK_API_SCHEMA_METHODS": `{"methods": [{"name": "users.get", "parameters": [],
+			"responses": {"response": {"$ref": "responses.json#/definitions/users_get_response"}}}]}`,
+	}
+
+	saved := make(map[string]string)
+
+	for k, v := range files {
+		fName := filepath.Join(dir, k+".json")
+
+		if err := ioutil.WriteFile(fName, []byte(v), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		saved[k], vkSchemaFiles[k] = vkSchemaFiles[k], fName
+	}
+
+	defer func() {
+		for k, v := range saved {
+			vkSchemaFiles[k] = v
+		}
+
+		objectsGlobal, responsesGlobal = nil, nil
+	}()
+
+	tests := []struct {
+		name    string
+		args    []string
+		wantLen int // number of top-level elements or properties
+		wantErr bool
+	}{
+		{"TestObject", []string{"users_user"}, 2, false},
+		{"TestObjectMinimal", []string{"-minimal", "users_user"}, 1, false},
+		{"TestResponse", []string{"-seed", "3", "users_get_response"}, -1, false},
+		{"TestMethod", []string{"users.get"}, -1, false},
+		// response referencing an object with the same name isn't a recursion
+		{"TestSameNames", []string{"base_ok_response"}, 1, false},
+		{"TestObjectRef", []string{"objects.json#/definitions/base_ok_response"}, 1, false},
+		{"TestUnknownRef", []string{"objects.json#/definitions/users_get_response"}, 0, true},
+		{"TestNotFound", []string{"users.search"}, 0, true},
+		{"TestNoName", []string{}, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+
+			err := runSample(tt.args, &out)
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("runSample() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			var v interface{}
+
+			if err := json.Unmarshal(out.Bytes(), &v); err != nil {
+				t.Fatalf("runSample() printed invalid JSON: %v", err)
+			}
+
+			switch val := v.(type) {
+			case map[string]interface{}:
+				if len(val) != tt.wantLen {
+					t.Errorf("runSample() = %v, want %d properties", val, tt.wantLen)
+				}
+			case []interface{}:
+				if tt.wantLen != -1 || len(val) == 0 {
+					t.Errorf("runSample() = %v, want non-empty array", val)
+				}
+			default:
+				t.Errorf("runSample() = %v, want object or array", val)
+			}
+		})
+	}
+}
@@ -0,0 +1,455 @@
+/*
+Copyright 2019 Konstantin Vasilev (burmuley@gmail.com)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"path"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// Kinds of values decoded and encoded by generated codecs
+const (
+	codecInt      = "int"
+	codecBool     = "bool"
+	codecString   = "string"
+	codecNumber   = "number"
+	codecNamed    = "named"    // a type with generated codecs
+	codecSlice    = "slice"    // a slice of values with codecs
+	codecPtr      = "ptr"      // a pointer to a value with codecs
+	codecFallback = "fallback" // any other type handled with `encoding/json`
+)
+
+// Underlying kinds of generated types registered in `codecTypes`
+const (
+	underlyingStruct = "struct"
+	underlyingValue  = "value"
+	underlyingNone   = "none" // interfaces and pointers can't have methods
+)
+
+// codecTypes: underlying kinds of generated types by qualified name (e.g. `objects.BaseBoolInt`),
+// filled when codecs are generated for `objects` and used for `responses` referencing them
+var codecTypes = make(map[string]string)
+
+// codecValue describes how a value of a Go type is decoded and encoded
+type codecValue struct {
+	Kind string      // value kind (one of `codec*` constants)
+	Type string      // Go type expression
+	Elem *codecValue // element of a slice or a pointer
+}
+
+// codecField is a struct field
+type codecField struct {
+	Name   string // Go field name
+	Key    string // JSON key
+	Prefix string // JSON written before the value: `{"key":` for the first field, `,"key":` for others
+	Value  *codecValue
+}
+
+// codecType is a generated type with codecs
+type codecType struct {
+	Name       string
+	Struct     bool
+	Fields     []codecField // fields of a struct type
+	Underlying *codecValue  // underlying type of a non-struct type
+}
+
+// Data structure passed to the codecs template
+type codecData struct {
+	Package string
+	Prefix  string
+	Imports []string
+	Types   []codecType
+	Cases   []roundTripCase // golden samples of types with codecs
+}
+
+// codecRef is a value and an expression referring to it passed to recursive `decode` and `encode` templates.
+// Decoders refer to a pointer to the value, encoders refer to the value itself.
+type codecRef struct {
+	V     *codecValue
+	Ref   string
+	Depth int // nesting level to name variables
+}
+
+// codecFile is a parsed Go file with generated types
+type codecFile struct {
+	prefix string
+	fset   *token.FileSet
+	specs  []*ast.TypeSpec
+}
+
+// codecImports: qualifiers of types referenced by generated codecs and their import paths
+var codecImports = map[string]string{
+	"json":      "encoding/json",
+	"objects":   objectsImportPath,
+	"responses": responsesImportPath,
+}
+
+var qualifierRe = regexp.MustCompile(`\b([a-z]+)\.[A-Z]`)
+
+// parseCodecFile: parses generated Go file collecting type declarations
+func parseCodecFile(fName, prefix string) (*codecFile, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, fName, nil, 0)
+
+	if err != nil {
+		return nil, err
+	}
+
+	cf := &codecFile{prefix: prefix, fset: fset}
+
+	for _, decl := range f.Decls {
+		if gd, ok := decl.(*ast.GenDecl); ok && gd.Tok == token.TYPE {
+			for _, spec := range gd.Specs {
+				// aliases can't have methods
+				if ts := spec.(*ast.TypeSpec); !ts.Assign.IsValid() {
+					cf.specs = append(cf.specs, ts)
+				}
+			}
+		}
+	}
+
+	return cf, nil
+}
+
+// registerCodecTypes: registers types of `files` in `codecTypes` resolving kinds of types defined by other named types
+func registerCodecTypes(pkg string, files []*codecFile) {
+	named := make(map[string]string)
+
+	for _, f := range files {
+		for _, ts := range f.specs {
+			name := pkg + "." + ts.Name.Name
+
+			switch t := ts.Type.(type) {
+			case *ast.StructType:
+				codecTypes[name] = underlyingStruct
+
+				if _, ok := structFields(t); !ok {
+					codecTypes[name] = underlyingNone
+				}
+			case *ast.InterfaceType, *ast.StarExpr, *ast.FuncType, *ast.ChanType:
+				codecTypes[name] = underlyingNone
+			case *ast.Ident:
+				if ast.IsExported(t.Name) {
+					named[name] = pkg + "." + t.Name
+				} else {
+					codecTypes[name] = underlyingValue
+				}
+			case *ast.SelectorExpr:
+				if qualified := exprString(f.fset, t); qualified == "json.Number" {
+					codecTypes[name] = underlyingValue
+				} else {
+					named[name] = qualified
+				}
+			default:
+				codecTypes[name] = underlyingValue
+			}
+		}
+	}
+
+	var resolve func(name string, seen map[string]bool) string
+
+	resolve = func(name string, seen map[string]bool) string {
+		if kind, ok := codecTypes[name]; ok {
+			return kind
+		}
+
+		target, ok := named[name]
+
+		if !ok || seen[name] {
+			return underlyingNone
+		}
+
+		seen[name] = true
+
+		return resolve(target, seen)
+	}
+
+	for name := range named {
+		codecTypes[name] = resolve(name, map[string]bool{})
+	}
+}
+
+// structFields: returns fields of a struct type with their JSON keys.
+// Structs with embedded fields, tag options or duplicate keys aren't supported.
+func structFields(t *ast.StructType) ([]codecField, bool) {
+	var fields []codecField
+
+	keys := make(map[string]bool)
+
+	for _, f := range t.Fields.List {
+		if len(f.Names) != 1 {
+			return nil, false
+		}
+
+		name := f.Names[0].Name
+
+		if !ast.IsExported(name) {
+			continue
+		}
+
+		key := name
+
+		if f.Tag != nil {
+			tag, err := strconv.Unquote(f.Tag.Value)
+
+			if err != nil {
+				return nil, false
+			}
+
+			if tagKey, ok := reflect.StructTag(tag).Lookup("json"); ok {
+				if tagKey == "-" {
+					continue
+				}
+
+				if strings.Contains(tagKey, ",") {
+					return nil, false
+				}
+
+				if tagKey != "" {
+					key = tagKey
+				}
+			}
+		}
+
+		if keys[key] {
+			return nil, false
+		}
+
+		keys[key] = true
+		fields = append(fields, codecField{Name: name, Key: key})
+	}
+
+	return fields, true
+}
+
+// exprString: formats Go type expression
+func exprString(fset *token.FileSet, expr ast.Expr) string {
+	var buf bytes.Buffer
+
+	if err := format.Node(&buf, fset, expr); err != nil {
+		return ""
+	}
+
+	return buf.String()
+}
+
+// codecValueOf: describes how a value of type `expr` defined in `pkg` is decoded and encoded
+func codecValueOf(pkg string, fset *token.FileSet, expr ast.Expr) *codecValue {
+	v := &codecValue{Kind: codecFallback, Type: exprString(fset, expr)}
+
+	switch t := expr.(type) {
+	case *ast.Ident:
+		switch t.Name {
+		case "int":
+			v.Kind = codecInt
+		case "bool":
+			v.Kind = codecBool
+		case "string":
+			v.Kind = codecString
+		default:
+			if kind := codecTypes[pkg+"."+t.Name]; kind != "" && kind != underlyingNone {
+				v.Kind = codecNamed
+			}
+		}
+	case *ast.SelectorExpr:
+		if v.Type == "json.Number" {
+			v.Kind = codecNumber
+		} else if kind := codecTypes[v.Type]; kind != "" && kind != underlyingNone {
+			v.Kind = codecNamed
+		}
+	case *ast.ArrayType:
+		// elements of slices and pointers are declared as variables, so they should be simple
+		if elem := codecValueOf(pkg, fset, t.Elt); t.Len == nil && elem.Kind != codecFallback {
+			v.Kind, v.Elem = codecSlice, elem
+		}
+	case *ast.StarExpr:
+		if elem := codecValueOf(pkg, fset, t.X); elem.Kind != codecFallback {
+			v.Kind, v.Elem = codecPtr, elem
+		}
+	}
+
+	return v
+}
+
+// typeRefs: collects type expressions written in generated code for `v`
+func (v *codecValue) typeRefs(refs map[string]bool) {
+	switch v.Kind {
+	case codecSlice:
+		refs[v.Type] = true
+		fallthrough
+	case codecPtr:
+		refs[v.Elem.Type] = true
+		v.Elem.typeRefs(refs)
+	}
+}
+
+// buildCodecs: builds codecs data of types declared in `f`
+func buildCodecs(pkg string, f *codecFile) codecData {
+	d := codecData{Package: pkg, Prefix: f.prefix}
+	refs := make(map[string]bool)
+
+	for _, ts := range f.specs {
+		kind := codecTypes[pkg+"."+ts.Name.Name]
+
+		if kind == "" || kind == underlyingNone {
+			continue
+		}
+
+		ct := codecType{Name: ts.Name.Name}
+
+		if st, ok := ts.Type.(*ast.StructType); ok {
+			ct.Struct = true
+			ct.Fields, _ = structFields(st)
+			fieldTypes := make(map[string]ast.Expr)
+
+			for _, field := range st.Fields.List {
+				fieldTypes[field.Names[0].Name] = field.Type
+			}
+
+			for i := range ct.Fields {
+				fld := &ct.Fields[i]
+				key, _ := json.Marshal(fld.Key)
+				fld.Prefix = fmt.Sprintf(",%s:", key)
+
+				if i == 0 {
+					fld.Prefix = "{" + fld.Prefix[1:]
+				}
+
+				fld.Value = codecValueOf(pkg, f.fset, fieldTypes[fld.Name])
+				fld.Value.typeRefs(refs)
+			}
+		} else {
+			ct.Underlying = codecValueOf(pkg, f.fset, ts.Type)
+			refs[ct.Underlying.Type] = true
+			ct.Underlying.typeRefs(refs)
+		}
+
+		d.Types = append(d.Types, ct)
+	}
+
+	imports := make(map[string]bool)
+
+	for ref := range refs {
+		for _, m := range qualifierRe.FindAllStringSubmatch(ref, -1) {
+			if imp, ok := codecImports[m[1]]; ok && m[1] != pkg {
+				imports[imp] = true
+			}
+		}
+	}
+
+	for imp := range imports {
+		d.Imports = append(d.Imports, imp)
+	}
+
+	sort.Strings(d.Imports)
+
+	return d
+}
+
+// codecFuncs: returns template functions used by the codecs template
+func codecFuncs() map[string]interface{} {
+	m := fillFuncs(make(map[string]interface{}))
+	m["codecRef"] = func(v *codecValue, ref string, depth int) codecRef {
+		return codecRef{V: v, Ref: ref, Depth: depth}
+	}
+	m["inc"] = func(i int) int {
+		return i + 1
+	}
+
+	return m
+}
+
+// generateCodecs: renders codecs of types declared in `<prefix>.go` files of `pkg` directory to `<prefix>_codec.go`
+// and their golden equivalence tests comparing codecs with `encoding/json` on `roundTrips` samples
+func generateCodecs(pkg string, prefixes map[string]struct{}, roundTrips []roundTripData) error {
+	_, tmplName := path.Split(codecsTmplName)
+
+	tmpl, err := template.New(tmplName).Funcs(codecFuncs()).ParseFiles(codecsTmplName)
+
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(prefixes))
+
+	for prefix := range prefixes {
+		names = append(names, prefix)
+	}
+
+	sort.Strings(names)
+
+	files := make([]*codecFile, 0, len(names))
+
+	for _, prefix := range names {
+		f, err := parseCodecFile(filepath.Join(outputDirName, pkg, prefix+".go"), prefix)
+
+		if err != nil {
+			return err
+		}
+
+		files = append(files, f)
+	}
+
+	registerCodecTypes(pkg, files)
+
+	samples := make(map[string][]roundTripCase)
+
+	for _, rt := range roundTrips {
+		samples[rt.Prefix] = rt.Cases
+	}
+
+	for _, f := range files {
+		d := buildCodecs(pkg, f)
+
+		if len(d.Types) == 0 {
+			continue
+		}
+
+		for _, c := range samples[f.prefix] {
+			if kind := codecTypes[pkg+"."+c.Name]; kind != "" && kind != underlyingNone {
+				d.Cases = append(d.Cases, c)
+			}
+		}
+
+		if err := renderFile(tmpl, d, pkg, fmt.Sprintf("%s_codec.go", f.prefix)); err != nil {
+			return err
+		}
+
+		if len(d.Cases) == 0 {
+			continue
+		}
+
+		if err := renderFile(tmpl.Lookup("codecs_test"), d, pkg, fmt.Sprintf("%s_codec_test.go", f.prefix)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,187 @@
+/*
+Copyright 2019 Konstantin Vasilev (burmuley@gmail.com)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"go/format"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"text/template"
+)
+
+func Test_buildCodecs(t *testing.T) {
+	sources := map[string]string{
+		"objects": `package objects
+
+type BaseBoolInt int
+
+type BaseObject interface{}
+
+type UsersUser struct {
+	Id       int          ` + "`json:\"id\"`" + `
+	Friends  []*UsersUser ` + "`json:\"friends\"`" + `
+	Verified BaseBoolInt  ` + "`json:\"verified\"`" + `
+	Rating   json.Number  ` + "`json:\"rating\"`" + `
+	Extra    BaseObject   ` + "`json:\"extra\"`" + `
+	Tags     []string     ` + "`json:\"tags\"`" + `
+}
+
+type UsersUsers []UsersUser
+
+type UsersAlias BaseBoolInt
+
+type UsersEmbedded struct {
+	UsersUser
+}
+`,
+		"responses": `package responses
+
+type UsersGet []objects.UsersUser
+
+type UsersGetMembers struct {
+	Count int ` + "`json:\"count\"`" + `
+	Items []struct {
+		objects.UsersUser
+	} ` + "`json:\"items\"`" + `
+}
+
+type UsersObject objects.BaseObject
+`,
+	}
+
+	dir, err := ioutil.TempDir("", "codecs")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	files := make(map[string]*codecFile)
+
+	for pkg, src := range sources {
+		fName := filepath.Join(dir, pkg+".go")
+
+		if err := ioutil.WriteFile(fName, []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		if files[pkg], err = parseCodecFile(fName, "users"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	defer func() { codecTypes = make(map[string]string) }()
+
+	registerCodecTypes("objects", []*codecFile{files["objects"]})
+	registerCodecTypes("responses", []*codecFile{files["responses"]})
+
+	wantKinds := map[string]string{
+		"objects.BaseBoolInt":       underlyingValue,
+		"objects.BaseObject":        underlyingNone,
+		"objects.UsersUser":         underlyingStruct,
+		"objects.UsersUsers":        underlyingValue,
+		"objects.UsersAlias":        underlyingValue,
+		"objects.UsersEmbedded":     underlyingNone,
+		"responses.UsersGet":        underlyingValue,
+		"responses.UsersGetMembers": underlyingStruct,
+		"responses.UsersObject":     underlyingNone,
+	}
+
+	if !reflect.DeepEqual(codecTypes, wantKinds) {
+		t.Errorf("registerCodecTypes() = %v, want %v", codecTypes, wantKinds)
+	}
+
+	kinds := func(d codecData) map[string][]string {
+		m := make(map[string][]string)
+
+		for _, ct := range d.Types {
+			if ct.Underlying != nil {
+				m[ct.Name] = append(m[ct.Name], ct.Underlying.Kind)
+			}
+
+			for _, f := range ct.Fields {
+				m[ct.Name] = append(m[ct.Name], f.Value.Kind)
+			}
+		}
+
+		return m
+	}
+
+	_, tmplName := path.Split(codecsTmplName)
+	tmpl, err := template.New(tmplName).Funcs(codecFuncs()).ParseFiles(codecsTmplName)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		pkg         string
+		wantKinds   map[string][]string
+		wantImports []string
+	}{
+		{
+			"TestObjects",
+			"objects",
+			map[string][]string{
+				"BaseBoolInt": {codecInt},
+				"UsersUser":   {codecInt, codecSlice, codecNamed, codecNumber, codecFallback, codecSlice},
+				"UsersUsers":  {codecSlice},
+				"UsersAlias":  {codecNamed},
+			},
+			nil,
+		},
+		{
+			"TestResponses",
+			"responses",
+			map[string][]string{
+				"UsersGet":        {codecSlice},
+				"UsersGetMembers": {codecInt, codecFallback},
+			},
+			[]string{objectsImportPath},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := buildCodecs(tt.pkg, files[tt.pkg])
+
+			if got := kinds(d); !reflect.DeepEqual(got, tt.wantKinds) {
+				t.Errorf("buildCodecs() kinds = %v, want %v", got, tt.wantKinds)
+			}
+
+			if !reflect.DeepEqual(d.Imports, tt.wantImports) {
+				t.Errorf("buildCodecs() imports = %v, want %v", d.Imports, tt.wantImports)
+			}
+
+			var buf bytes.Buffer
+
+			if err := tmpl.Execute(&buf, d); err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := format.Source(buf.Bytes()); err != nil {
+				t.Errorf("rendered codecs are invalid: %v\n%s", err, buf.String())
+			}
+		})
+	}
+}
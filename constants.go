@@ -17,6 +17,9 @@ package main
 
 var (
 	outputDirName string = "output"
+
+	// generate reflection-free JSON codecs for objects and responses (`VK_API_GEN_CODECS` environment variable)
+	genCodecs bool
 )
 
 // Output directories and paths to templates
@@ -29,6 +32,18 @@ const (
 
 	methodsHeaderTmplName = "templates/methods.header.template"
 	methodsTmplName       = "templates/methods.template"
+
+	clientsTmplName = "templates/clients.template"
+
+	eventsTmplName = "templates/events.template"
+
+	fakeServerTmplName = "templates/fakeserver.template"
+	fakesTmplName      = "templates/fakes.template"
+
+	roundTripTmplName = "templates/roundtrip.template"
+	codecsTmplName    = "templates/codecs.template"
+
+	validationTmplName = "templates/validation.template"
 )
 
 const (
@@ -36,6 +51,12 @@ const (
 	responsesImportPath = "github.com/Burmuley/go-vkapi/responses"
 )
 
+// Prefixes of references to objects and responses schemas definitions
+const (
+	objectsRefPrefix   = "objects.json#/definitions/"
+	responsesRefPrefix = "responses.json#/definitions/"
+)
+
 // Response and Object types
 const (
 	schemaTypeInt       string = "integer"
@@ -49,3 +70,14 @@ const (
 	schemaTypeUnknown   string = "UNKNOWN"
 	schemaTypeMultiple  string = "multiple"
 )
+
+// Access token types (`access_token_type` field of a method in methods schema)
+const (
+	tokenTypeUser    string = "user"
+	tokenTypeGroup   string = "group"
+	tokenTypeService string = "service"
+	tokenTypeOpen    string = "open"
+)
+
+// Token types to generate separate API clients for
+var clientTokenTypes = []string{tokenTypeUser, tokenTypeGroup, tokenTypeService}
@@ -0,0 +1,114 @@
+/*
+Copyright 2019 Konstantin Vasilev (burmuley@gmail.com)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Objects used for events whose object is not named `callback_<event type>`.
+// Candidates are checked in order, the first one found in the objects schema is used.
+var callbackEventObjects = map[string][]string{
+	"message_new":           {"callback_message_object", "messages_message"},
+	"message_reply":         {"messages_message"},
+	"message_edit":          {"messages_message"},
+	"message_allow":         nil,
+	"message_deny":          nil,
+	"photo_new":             {"photos_photo"},
+	"video_new":             {"video_video"},
+	"wall_post_new":         {"wall_wallpost_full", "wall_wallpost"},
+	"wall_repost":           {"wall_wallpost_full", "wall_wallpost"},
+	"wall_reply_new":        {"wall_wall_comment"},
+	"wall_reply_edit":       {"wall_wall_comment"},
+	"wall_reply_restore":    {"wall_wall_comment"},
+	"wall_reply_delete":     nil,
+	"board_post_delete":     nil,
+	"photo_comment_delete":  nil,
+	"video_comment_delete":  nil,
+	"market_comment_delete": nil,
+	"poll_vote_new":         nil,
+	"group_join":            nil,
+	"group_leave":           nil,
+	"group_officers_edit":   nil,
+	"group_change_settings": nil,
+	"group_change_photo":    nil,
+	"user_block":            nil,
+	"user_unblock":          nil,
+}
+
+// Event types without an object
+var callbackSkipEvents = map[string]struct{}{
+	"confirmation": {},
+}
+
+// Bots Long Poll and Callback API event with the type of its object
+type callbackEvent struct {
+	Type       string // event type, e.g. `message_new`
+	ObjectType string // Go type of the event object
+}
+
+// Data structure passed to the events template
+type eventsData struct {
+	Imports map[string]struct{}
+	Events  []callbackEvent
+}
+
+// buildEvents: detects Bots Long Poll and Callback API events in the objects schema.
+// Event types are taken from `callback_type` enum (or the list of known events if it's missing),
+// events without a matching object are omitted.
+func buildEvents(o *objectsSchema) eventsData {
+	data := eventsData{Imports: make(map[string]struct{})}
+	types := make([]string, 0)
+
+	if t, ok := o.Definitions["callback_type"]; ok && len(t.Enum) > 0 {
+		for _, v := range t.Enum {
+			types = append(types, fmt.Sprint(v))
+		}
+	} else {
+		for k := range callbackEventObjects {
+			types = append(types, k)
+		}
+	}
+
+	sort.Strings(types)
+
+	for _, t := range types {
+		if _, ok := callbackSkipEvents[t]; ok {
+			continue
+		}
+
+		candidates := append(append([]string{}, callbackEventObjects[t]...), fmt.Sprintf("callback_%s", t))
+
+		for _, c := range candidates {
+			if _, ok := o.Definitions[c]; ok {
+				data.Events = append(data.Events, callbackEvent{
+					Type:       t,
+					ObjectType: getObjectTypeName(fmt.Sprintf("objects.json#/definitions/%s", c)),
+				})
+				break
+			}
+		}
+	}
+
+	if len(data.Events) > 0 {
+		data.Imports["context"] = struct{}{}
+		data.Imports["encoding/json"] = struct{}{}
+		data.Imports[objectsImportPath] = struct{}{}
+	}
+
+	return data
+}
@@ -0,0 +1,77 @@
+/*
+Copyright 2019 Konstantin Vasilev (burmuley@gmail.com)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_buildEvents(t *testing.T) {
+	tests := []struct {
+		name        string
+		definitions map[string]schemaJSONProperty
+		want        []callbackEvent
+	}{
+		{
+			"TestCallbackTypeEnum",
+			map[string]schemaJSONProperty{
+				"callback_type":          {Enum: []interface{}{"message_new", "group_join", "confirmation", "like_add"}},
+				"callback_group_join":    {},
+				"callback_confirmation":  {},
+				"messages_message":       {},
+				"callback_message_allow": {},
+			},
+			[]callbackEvent{
+				{Type: "group_join", ObjectType: "objects.CallbackGroupJoin"},
+				{Type: "message_new", ObjectType: "objects.MessagesMessage"},
+			},
+		},
+		{
+			"TestKnownEvents",
+			map[string]schemaJSONProperty{
+				"callback_message_object": {},
+				"messages_message":        {},
+				"callback_message_allow":  {},
+			},
+			[]callbackEvent{
+				{Type: "message_allow", ObjectType: "objects.CallbackMessageAllow"},
+				{Type: "message_edit", ObjectType: "objects.MessagesMessage"},
+				{Type: "message_new", ObjectType: "objects.CallbackMessageObject"},
+				{Type: "message_reply", ObjectType: "objects.MessagesMessage"},
+			},
+		},
+		{
+			"TestNoEvents",
+			map[string]schemaJSONProperty{"users_user": {}},
+			nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := buildEvents(&objectsSchema{Definitions: tt.definitions})
+
+			if !reflect.DeepEqual(got.Events, tt.want) {
+				t.Errorf("buildEvents() = %v, want %v", got.Events, tt.want)
+			}
+
+			if _, ok := got.Imports[objectsImportPath]; ok != (len(tt.want) > 0) {
+				t.Errorf("buildEvents() imports = %v", got.Imports)
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+/*
+Copyright 2019 Konstantin Vasilev (burmuley@gmail.com)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import "sort"
+
+// Method parameter checked by the fake server
+type fakeParam struct {
+	Name      string
+	Type      string // schema type of the parameter
+	ItemsType string // schema type of array elements
+	Required  bool
+}
+
+// Method served by the fake server
+type fakeMethod struct {
+	Name     string
+	Params   []fakeParam
+	Response string // sample JSON response
+}
+
+// Data structure passed to the fake server template
+type fakeServerData struct {
+	Methods []fakeMethod
+}
+
+// buildFakeServer: collects parameters and sample responses of `methods` sorted by name
+func buildFakeServer(methods []schemaMethod) fakeServerData {
+	data := fakeServerData{}
+
+	for _, m := range methods {
+		fm := fakeMethod{Name: m.GetName(), Response: sampleResponse(m)}
+
+		for _, p := range m.Params {
+			fp := fakeParam{Name: p.Name, Type: p.GetType(), Required: p.Required}
+
+			if p.GetType() == schemaTypeArray && p.Items != nil {
+				fp.ItemsType = p.Items.GetType()
+			}
+
+			fm.Params = append(fm.Params, fp)
+		}
+
+		data.Methods = append(data.Methods, fm)
+	}
+
+	sort.Slice(data.Methods, func(i, j int) bool { return data.Methods[i].Name < data.Methods[j].Name })
+
+	return data
+}
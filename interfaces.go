@@ -35,6 +35,8 @@ type IMethod interface {
 	GetName() string
 	GetDescription() string
 	IsExtended() bool
+	IsAllowedFor(tokenType string) bool
+	GetPager() *methodPager
 }
 
 type IMethodItem interface {
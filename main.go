@@ -19,6 +19,7 @@ package main
 import (
 	"fmt"
 	"os"
+	"strconv"
 )
 
 type step struct {
@@ -46,10 +47,15 @@ var (
 	// copy objects container to render `allOf` and `oneOf` properties in responses
 	objectsGlobal *objectsSchema
 
+	// copy responses container to detect paginated methods
+	responsesGlobal *responsesSchema
+
 	// hash of output directories names
 	outputDirs = []string{
 		fmt.Sprintf("%s/objects", outputDirName),
 		fmt.Sprintf("%s/responses", outputDirName),
+		fmt.Sprintf("%s/events", outputDirName),
+		fmt.Sprintf("%s/vkapitest", outputDirName),
 	}
 )
 
@@ -64,6 +70,10 @@ func readEnvVariables() {
 	if tmp := os.Getenv("VK_API_SCHEMA_OUTPUT"); tmp != "" {
 		outputDirName = tmp
 	}
+
+	if tmp, err := strconv.ParseBool(os.Getenv("VK_API_GEN_CODECS")); err == nil {
+		genCodecs = tmp
+	}
 }
 
 // printEnvInfo: print runtime environment information
@@ -73,10 +83,22 @@ func printEnvInfo() {
 	for k, v := range vkSchemaFiles {
 		logInfo(fmt.Sprintf("%s = %s", k, v))
 	}
+
+	logInfo(fmt.Sprintf("VK_API_GEN_CODECS = %t", genCodecs))
 }
 
 func main() {
 	readEnvVariables()
+
+	if len(os.Args) > 1 && os.Args[1] == "sample" {
+		if err := runSample(os.Args[2:], os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		return
+	}
+
 	printEnvInfo()
 
 	// check and create output directories
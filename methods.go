@@ -19,6 +19,7 @@ import (
     "encoding/json"
     "fmt"
     "path"
+    "strings"
     "text/template"
 )
 
@@ -75,6 +76,12 @@ func (s *schemaMethods) Parse(fPath string) error {
     for k := range s.Methods {
         s.keys = append(s.keys, s.Methods[k].GetName())
         mPref := getApiNamePrefix(s.Methods[k].GetName())
+        s.Methods[k].Pager = detectPager(s.Methods[k])
+
+        // iterators over paginated results return items types
+        if s.Methods[k].Pager != nil && strings.HasPrefix(s.Methods[k].Pager.ItemType, "objects.") {
+            addImport(s.imports, mPref, objectsImportPath)
+        }
 
         // Inspect parameters and fill imports
         if checkMImports(s.Methods[k].GetParameters(), "objects.") {
@@ -102,7 +109,8 @@ func (s *schemaMethods) Parse(fPath string) error {
     return nil
 }
 
-func (s *schemaMethods) Generate(outputDir string) error {
+// methodFuncs: returns template functions used by the methods templates
+func methodFuncs() map[string]interface{} {
     tmplFuncs := make(map[string]interface{})
     tmplFuncs = fillFuncs(tmplFuncs)
     tmplFuncs["convertParam"] = convertParam
@@ -122,6 +130,12 @@ func (s *schemaMethods) Generate(outputDir string) error {
         return string(s[0])
     }
 
+    return tmplFuncs
+}
+
+func (s *schemaMethods) Generate(outputDir string) error {
+    tmplFuncs := methodFuncs()
+
     _, tmplName := path.Split(methodsTmplName)
 
     tmpl, err := template.New(tmplName).Funcs(tmplFuncs).ParseFiles(methodsTmplName)
@@ -146,5 +160,67 @@ func (s *schemaMethods) Generate(outputDir string) error {
 
     generateItems(s, hTmpl, tmpl, "/", prefixes, s.imports)
 
-    return nil
+    // clients template reuses function signatures defined in methods template
+    _, cTmplName := path.Split(clientsTmplName)
+
+    cTmpl, err := template.New(cTmplName).Funcs(tmplFuncs).ParseFiles(clientsTmplName, methodsTmplName)
+
+    if err != nil {
+        return err
+    }
+
+    methods := make([]IMethod, len(s.Methods))
+
+    for k := range s.Methods {
+        methods[k] = s.Methods[k]
+    }
+
+    clients := buildClients(methods)
+
+    if err := renderFile(cTmpl, clients, "/", "clients.go"); err != nil {
+        return err
+    }
+
+    // fakes implementing methods groups interfaces
+    _, fkTmplName := path.Split(fakesTmplName)
+
+    fkTmpl, err := template.New(fkTmplName).Funcs(tmplFuncs).ParseFiles(fakesTmplName, methodsTmplName)
+
+    if err != nil {
+        return err
+    }
+
+    if err := renderFile(fkTmpl, clients, "vkapitest", "fakes.go"); err != nil {
+        return err
+    }
+
+    // schemas to validate API responses in debug mode
+    _, vTmplName := path.Split(validationTmplName)
+
+    vTmpl, err := template.New(vTmplName).ParseFiles(validationTmplName)
+
+    if err != nil {
+        return err
+    }
+
+    validation, err := buildValidation(s.Methods)
+
+    if err != nil {
+        return err
+    }
+
+    if err := renderFile(vTmpl, validation, "/", "schemas.go"); err != nil {
+        return err
+    }
+
+    // fake API server for tests
+    _, fTmplName := path.Split(fakeServerTmplName)
+
+    fTmpl, err := template.New(fTmplName).Funcs(tmplFuncs).ParseFiles(fakeServerTmplName)
+
+    if err != nil {
+        return err
+    }
+
+    return renderFile(fTmpl, buildFakeServer(s.Methods), "vkapitest", "methods.go")
 }
@@ -0,0 +1,74 @@
+/*
+Copyright 2019 Konstantin Vasilev (burmuley@gmail.com)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"bytes"
+	"path"
+	"strings"
+	"testing"
+	"text/template"
+)
+
+func Test_methodParamsFill(t *testing.T) {
+	_, tmplName := path.Split(methodsTmplName)
+	tmpl, err := template.New(tmplName).Funcs(methodFuncs()).ParseFiles(methodsTmplName)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	method := schemaMethod{
+		Name: "photos.getUploadServer",
+		Params: []*schemaMethodItem{
+			{Name: "album_id", Type: schemaTypeInt, Required: true},
+			{Name: "group_id", Type: schemaTypeInt},
+			{Name: "compression", Type: schemaTypeBoolean},
+			{Name: "caption", Type: schemaTypeString},
+		},
+	}
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"TestRequiredInt", `params["album_id"] = EncodeInt(albumId)`},
+		// negative ids of communities must reach the API, only zero value is omitted
+		{"TestOptionalInt", `if groupId != 0 { params["group_id"] = EncodeInt(groupId) }`},
+		// optional booleans are always sent, `false` as `0`
+		{"TestOptionalBool", `} params["compression"] = EncodeBool(compression) if`},
+		{"TestOptionalString", `if caption != "" { params["caption"] = caption }`},
+	}
+
+	var buf bytes.Buffer
+
+	if err := tmpl.ExecuteTemplate(&buf, "function_params_fill", struct {
+		M IMethod
+		C int
+	}{M: method}); err != nil {
+		t.Fatal(err)
+	}
+
+	got := strings.Join(strings.Fields(buf.String()), " ")
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !strings.Contains(got, tt.want) {
+				t.Errorf("function_params_fill = %q, want it to contain %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -98,7 +98,26 @@ func (o *objectsSchema) Generate(outputDir string) error {
 
     generateItems(o, hTmpl, tmpl, "objects", prefixes, o.imports)
 
-    return nil
+    if err := generateRoundTrips("objects", o.Definitions, false, convertName); err != nil {
+        return err
+    }
+
+    if genCodecs {
+        if err := generateCodecs("objects", prefixes, buildRoundTrips("objects", o.Definitions, false, convertName)); err != nil {
+            return err
+        }
+    }
+
+    // typed Bots Long Poll and Callback API events
+    _, eTmplName := path.Split(eventsTmplName)
+
+    eTmpl, err := template.New(eTmplName).Funcs(tmplFuncs).ParseFiles(eventsTmplName)
+
+    if err != nil {
+        return err
+    }
+
+    return renderFile(eTmpl, buildEvents(o), "events", "events.go")
 }
 
 func (o *objectsSchema) Parse(fPath string) error {
@@ -0,0 +1,135 @@
+/*
+Copyright 2019 Konstantin Vasilev (burmuley@gmail.com)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import "strings"
+
+// Pagination kinds
+const (
+	pagerOffset = "offset" // `offset`/`count` parameters, `count`/`items` in response
+	pagerCursor = "cursor" // `start_from` parameter, `next_from`/`items` in response
+)
+
+// Pagination of method results detected in schema
+type methodPager struct {
+	Kind     string // one of pagination kinds
+	PageSize int    // maximum value of `count` parameter, `0` if not defined in schema
+	ItemType string // Go type of `items` elements
+}
+
+// detectPager: detects pagination of `m` results, returns nil if results are not paginated
+func detectPager(m schemaMethod) *methodPager {
+	if m.Responses.Response == nil {
+		return nil
+	}
+
+	props := responseProperties(m.Responses.Response.Ref)
+	itemType, ok := itemsType(props)
+
+	if !ok {
+		return nil
+	}
+
+	count := findParam(m, "count")
+	pageSize := 0
+
+	if count != nil && count.GetType() == schemaTypeInt {
+		pageSize = count.Maximum
+	}
+
+	// cursor-based methods may also have `offset` parameter, so they are detected first
+	if startFrom := findParam(m, "start_from"); startFrom != nil && startFrom.GetType() == schemaTypeString &&
+		props["next_from"].GetType() == schemaTypeString {
+		return &methodPager{Kind: pagerCursor, PageSize: pageSize, ItemType: itemType}
+	}
+
+	offset := findParam(m, "offset")
+
+	if offset == nil || count == nil || offset.GetType() != schemaTypeInt || count.GetType() != schemaTypeInt {
+		return nil
+	}
+
+	if props["count"].GetType() != schemaTypeInt {
+		return nil
+	}
+
+	return &methodPager{Kind: pagerOffset, PageSize: pageSize, ItemType: itemType}
+}
+
+// findParam: returns parameter `name` of method `m` or nil if the method doesn't have it
+func findParam(m schemaMethod, name string) *schemaMethodItem {
+	for _, v := range m.Params {
+		if v.Name == name {
+			return v
+		}
+	}
+
+	return nil
+}
+
+// itemsType: returns Go type of `items` array elements of response properties `props`.
+// Returns false if there's no `items` or the type can't be used in an iterator.
+func itemsType(props map[string]schemaJSONProperty) (string, bool) {
+	items, ok := props["items"]
+
+	if !ok || items.GetType() != schemaTypeArray || items.Items == nil || items.Items.Items == nil {
+		return "", false
+	}
+
+	item := items.Items.Items
+
+	// nested arrays and multiple types are rendered differently in responses
+	if item.GetType() == schemaTypeArray || item.GetType() == schemaTypeMultiple {
+		return "", false
+	}
+
+	// references inside responses schema can't be resolved from generated methods
+	if item.GetType() == schemaTypeBuiltin && !strings.HasPrefix(item.Ref, "objects.json") {
+		return "", false
+	}
+
+	return item.GetGoType(), true
+}
+
+// responseProperties: returns properties of `response` object of the method response referenced by `ref`
+func responseProperties(ref string) map[string]schemaJSONProperty {
+	if responsesGlobal == nil {
+		return nil
+	}
+
+	def, ok := responsesGlobal.Definitions[nameFRef(ref)]
+
+	if !ok {
+		return nil
+	}
+
+	resp, ok := def.Properties["response"]
+
+	if !ok || resp == nil {
+		return nil
+	}
+
+	// response may be a reference to an object
+	if resp.Ref != "" {
+		if objectsGlobal == nil {
+			return nil
+		}
+
+		return objectsGlobal.Definitions[nameFRef(resp.Ref)].GetProperties()
+	}
+
+	return resp.GetProperties()
+}
@@ -0,0 +1,108 @@
+/*
+Copyright 2019 Konstantin Vasilev (burmuley@gmail.com)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func Test_detectPager(t *testing.T) {
+	responses := `{"definitions": {
+		"wall_get_response": {"type": "object", "properties": {"response": {"type": "object", "properties": {
+			"count": {"type": "integer"},
+			"items": {"type": "array", "items": {"$ref": "objects.json#/definitions/wall_wallpost_full"}}
+		}}}},
+		"friends_get_response": {"type": "object", "properties": {"response": {"$ref": "objects.json#/definitions/friends_list"}}},
+		"newsfeed_get_response": {"type": "object", "properties": {"response": {"type": "object", "properties": {
+			"next_from": {"type": "string"},
+			"items": {"type": "array", "items": {"$ref": "objects.json#/definitions/newsfeed_newsfeed_item"}}
+		}}}},
+		"users_get_response": {"type": "object", "properties": {"response": {"type": "array", "items": {"type": "integer"}}}}
+	}}`
+	objects := `{"definitions": {
+		"friends_list": {"type": "object", "properties": {
+			"count": {"type": "integer"},
+			"items": {"type": "array", "items": {"type": "integer"}}
+		}}
+	}}`
+
+	objectsGlobal, responsesGlobal = &objectsSchema{}, &responsesSchema{}
+
+	if err := json.Unmarshal([]byte(objects), objectsGlobal); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := json.Unmarshal([]byte(responses), responsesGlobal); err != nil {
+		t.Fatal(err)
+	}
+
+	defer func() { objectsGlobal, responsesGlobal = nil, nil }()
+
+	paged := []*schemaMethodItem{{Name: "offset", Type: "integer"}, {Name: "count", Type: "integer", Maximum: 100}}
+	method := func(name, resp string, params []*schemaMethodItem) schemaMethod {
+		m := schemaMethod{Name: name, Params: params}
+		m.Responses.Response = &schemaMethodItem{Ref: "responses.json#/definitions/" + resp}
+
+		return m
+	}
+
+	tests := []struct {
+		name   string
+		method schemaMethod
+		want   *methodPager
+	}{
+		{
+			"TestObjectItems",
+			method("wall.get", "wall_get_response", paged),
+			&methodPager{Kind: pagerOffset, PageSize: 100, ItemType: "objects.WallWallpostFull"},
+		},
+		{
+			"TestNoCountParam",
+			method("friends.get", "friends_get_response", paged[:1:1]),
+			nil,
+		},
+		{
+			"TestResponseObjectReference",
+			method("friends.get", "friends_get_response", []*schemaMethodItem{{Name: "count", Type: "integer"}, {Name: "offset", Type: "integer"}}),
+			&methodPager{Kind: pagerOffset, ItemType: "int"},
+		},
+		{
+			"TestCursor",
+			method("newsfeed.get", "newsfeed_get_response", append([]*schemaMethodItem{{Name: "start_from", Type: "string"}}, paged...)),
+			&methodPager{Kind: pagerCursor, PageSize: 100, ItemType: "objects.NewsfeedNewsfeedItem"},
+		},
+		{
+			"TestCursorNoStartFrom",
+			method("newsfeed.get", "newsfeed_get_response", paged),
+			nil,
+		},
+		{
+			"TestNoItems",
+			method("users.get", "users_get_response", paged),
+			nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := detectPager(tt.method); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("detectPager() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -89,7 +89,19 @@ func (r *responsesSchema) Generate(outputDir string) error {
 
 	generateItems(r, hTmpl, tmpl, "responses", prefixes, r.imports)
 
-	return nil
+	typeName := func(name string) string {
+		return cutSuffix(convertName(name), "Response")
+	}
+
+	if err := generateRoundTrips("responses", r.Definitions, true, typeName); err != nil {
+		return err
+	}
+
+	if !genCodecs {
+		return nil
+	}
+
+	return generateCodecs("responses", prefixes, buildRoundTrips("responses", r.Definitions, true, typeName))
 }
 
 func (r *responsesSchema) Parse(fPath string) error {
@@ -115,6 +127,7 @@ func (r *responsesSchema) Parse(fPath string) error {
 		return fmt.Errorf("JSON Error: %s", err)
 	}
 
+	responsesGlobal = r
 	r.imports = make(map[string]map[string]struct{})
 
 	for k := range r.Definitions {
@@ -0,0 +1,110 @@
+/*
+Copyright 2019 Konstantin Vasilev (burmuley@gmail.com)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"text/template"
+)
+
+// Round-trip test case of a generated type
+type roundTripCase struct {
+	Name   string // Go type name
+	Sample string // schema-conformant JSON sample
+}
+
+// Data structure passed to the round-trip tests template
+type roundTripData struct {
+	Package string
+	Prefix  string
+	Cases   []roundTripCase
+}
+
+// roundTripTypes: schema types rendered as Go types which JSON representation matches the schema.
+// Multiple types (`allOf`/`oneOf` definitions) are rendered as structs and are not tested.
+var roundTripTypes = map[string]bool{
+	schemaTypeObject:  true,
+	schemaTypeArray:   true,
+	schemaTypeBuiltin: true,
+	schemaTypeInt:     true,
+	schemaTypeNumber:  true,
+	schemaTypeString:  true,
+}
+
+// buildRoundTrips: builds round-trip test cases for `definitions` grouped by API name prefix.
+// For responses (`response` is true) samples are synthesized from the `response` property,
+// `typeName` converts a definition name to the Go type name.
+func buildRoundTrips(pkg string, definitions map[string]schemaJSONProperty, response bool, typeName func(string) string) []roundTripData {
+	groups := make(map[string][]roundTripCase)
+
+	for k := range definitions {
+		def := definitions[k]
+		p := &def
+		ref := objectsRefPrefix + k
+
+		if response {
+			p, ref = def.Properties["response"], responsesRefPrefix+k
+		}
+
+		if p == nil || !roundTripTypes[p.GetType()] {
+			continue
+		}
+
+		// the definition itself is on the path to omit self-referencing properties
+		b, err := json.Marshal(sampleValue(p, map[string]bool{ref: true}))
+
+		if err != nil {
+			continue
+		}
+
+		prefix := getApiNamePrefix(k)
+		groups[prefix] = append(groups[prefix], roundTripCase{Name: typeName(k), Sample: string(b)})
+	}
+
+	result := make([]roundTripData, 0, len(groups))
+
+	for prefix, cases := range groups {
+		sort.Slice(cases, func(i, j int) bool { return cases[i].Name < cases[j].Name })
+		result = append(result, roundTripData{Package: pkg, Prefix: prefix, Cases: cases})
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].Prefix < result[j].Prefix })
+
+	return result
+}
+
+// generateRoundTrips: renders a round-trip test file for each group of `definitions` to `pkg` directory
+func generateRoundTrips(pkg string, definitions map[string]schemaJSONProperty, response bool, typeName func(string) string) error {
+	_, tmplName := path.Split(roundTripTmplName)
+
+	tmpl, err := template.New(tmplName).Funcs(fillFuncs(make(map[string]interface{}))).ParseFiles(roundTripTmplName)
+
+	if err != nil {
+		return err
+	}
+
+	for _, d := range buildRoundTrips(pkg, definitions, response, typeName) {
+		if err := renderFile(tmpl, d, pkg, fmt.Sprintf("%s_test.go", d.Prefix)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,93 @@
+/*
+Copyright 2019 Konstantin Vasilev (burmuley@gmail.com)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func Test_buildRoundTrips(t *testing.T) {
+	objects := `{"definitions": {
+		"base_bool_int": {"type": "integer", "enum": [0, 1]},
+		"base_ok_response": {"type": "integer", "enum": [1]},
+		"users_user": {"type": "object", "properties": {
+			"id": {"type": "integer"},
+			"friends": {"type": "array", "items": {"$ref": "#/definitions/users_user"}},
+			"verified": {"$ref": "#/definitions/base_bool_int"}
+		}},
+		"users_user_min": {"allOf": [{"$ref": "#/definitions/users_user"}]}
+	}}`
+	responses := `{"definitions": {
+		"base_ok_response": {"type": "object", "properties": {"response": {"$ref": "objects.json#/definitions/base_ok_response"}}},
+		"users_get_response": {"type": "object", "properties": {"response": {"type": "array", "items": {"$ref": "objects.json#/definitions/users_user"}}}},
+		"users_search_response": {"type": "object", "properties": {"response": {"oneOf": [{"type": "integer"}, {"type": "string"}]}}}
+	}}`
+
+	objectsGlobal, responsesGlobal = &objectsSchema{}, &responsesSchema{}
+
+	if err := json.Unmarshal([]byte(objects), objectsGlobal); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := json.Unmarshal([]byte(responses), responsesGlobal); err != nil {
+		t.Fatal(err)
+	}
+
+	defer func() { objectsGlobal, responsesGlobal = nil, nil }()
+
+	tests := []struct {
+		name        string
+		pkg         string
+		definitions map[string]schemaJSONProperty
+		response    bool
+		want        []roundTripData
+	}{
+		{
+			"TestObjects",
+			"objects",
+			objectsGlobal.Definitions,
+			false,
+			[]roundTripData{
+				{"objects", "base", []roundTripCase{{"base_bool_int", `0`}, {"base_ok_response", `1`}}},
+				{"objects", "users", []roundTripCase{{"users_user", `{"friends":[],"id":1,"verified":0}`}}},
+			},
+		},
+		{
+			"TestResponses",
+			"responses",
+			responsesGlobal.Definitions,
+			true,
+			[]roundTripData{
+				// response referencing an object with the same name isn't a recursion
+				{"responses", "base", []roundTripCase{{"base_ok_response", `1`}}},
+				{"responses", "users", []roundTripCase{{"users_get_response", `[{"friends":[],"id":1,"verified":0}]`}}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := buildRoundTrips(tt.pkg, tt.definitions, tt.response, func(s string) string { return s })
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("buildRoundTrips() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,324 @@
+/*
+Copyright 2019 Konstantin Vasilev (burmuley@gmail.com)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+)
+
+// sampleResponse: synthesizes a sample JSON response of method `m` from responses and objects schemas.
+// Values are random, the sampler is seeded with the method name, so the same schema produces the same samples.
+// Returns `null` if the response can't be resolved.
+func sampleResponse(m schemaMethod) string {
+	var value interface{}
+
+	if r := m.Responses.Response; r != nil {
+		value = newSampler(sampleSeed(m.GetName()), false).value("", resolveRef(r.Ref))
+	}
+
+	b, err := json.Marshal(value)
+
+	if err != nil {
+		return "null"
+	}
+
+	return string(b)
+}
+
+// sampleValue: synthesizes a sample value of `p` resolving references in objects and responses schemas.
+// Values are constant: the first enum value, `1` for numbers, `"string"` for strings
+// and one element for arrays. `path` contains references being resolved (see `qualifyRef`),
+// a self-referencing object is omitted to stop recursion.
+func sampleValue(p *schemaJSONProperty, path map[string]bool) interface{} {
+	s := &sampler{path: path}
+
+	return s.value("", p)
+}
+
+// sampleSeed: returns a random seed derived from `name`
+func sampleSeed(name string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte(name))
+
+	return int64(h.Sum64())
+}
+
+// Words to compose sample strings of
+var (
+	sampleFirstNames = []string{"Pavel", "Anna", "Ivan", "Maria", "Nikolai", "Olga"}
+	sampleLastNames  = []string{"Durov", "Ivanova", "Petrov", "Smirnova", "Sokolov", "Popova"}
+	sampleWords      = []string{"hello", "world", "photo", "music", "summer", "friends", "news", "city", "party", "weekend"}
+)
+
+// sampler synthesizes sample values of schema definitions.
+// Without `rand` values are constant, otherwise they are random but the same for the same seed:
+// schema examples are used where they match the type, realistic values are chosen by string formats
+// and property names (ids, dates, counters, names, urls).
+type sampler struct {
+	rand    *rand.Rand
+	minimal bool            // include only required object properties
+	path    map[string]bool // references being resolved
+}
+
+// newSampler: returns a sampler producing random values for `seed`
+func newSampler(seed int64, minimal bool) *sampler {
+	return &sampler{rand: rand.New(rand.NewSource(seed)), minimal: minimal, path: map[string]bool{}}
+}
+
+// value: synthesizes a sample value of property `name` described by `p`
+func (s *sampler) value(name string, p *schemaJSONProperty) interface{} {
+	if p == nil {
+		return nil
+	}
+
+	if len(p.Ref) > 0 {
+		// objects and responses can have the same names, the key includes the schema file
+		ref := qualifyRef(p.Ref)
+
+		if s.path[ref] {
+			return nil
+		}
+
+		s.path[ref] = true
+		defer delete(s.path, ref)
+
+		return s.value(name, resolveRef(p.Ref))
+	}
+
+	if len(p.AllOf) > 0 {
+		merged := make(map[string]interface{})
+
+		for _, v := range p.AllOf {
+			if obj, ok := s.value(name, v).(map[string]interface{}); ok {
+				for k, vv := range obj {
+					merged[k] = vv
+				}
+			}
+		}
+
+		return merged
+	}
+
+	if len(p.OneOf) > 0 {
+		return s.value(name, p.OneOf[s.intn(len(p.OneOf))])
+	}
+
+	if len(p.Enum) > 0 {
+		return p.Enum[s.intn(len(p.Enum))]
+	}
+
+	if s.rand != nil && exampleFits(p) {
+		return p.Example
+	}
+
+	switch p.GetType() {
+	case schemaTypeInt:
+		return s.integer(name)
+	case schemaTypeNumber:
+		if s.rand == nil {
+			return 1
+		}
+
+		return float64(s.rand.Intn(10000)) / 100
+	case schemaTypeString:
+		return s.string(name, p.Format)
+	case schemaTypeBoolean:
+		return s.rand == nil || s.rand.Intn(2) == 1
+	case schemaTypeArray:
+		if p.Items == nil {
+			return []interface{}{}
+		}
+
+		if p.Items.ItemsArr != nil {
+			items := make([]interface{}, 0, len(p.Items.ItemsArr))
+
+			for _, v := range p.Items.ItemsArr {
+				items = append(items, s.value(name, v))
+			}
+
+			return items
+		}
+
+		items := make([]interface{}, 0)
+
+		for i := 1 + s.intn(3); i > 0; i-- {
+			if item := s.value(name, p.Items.Items); item != nil {
+				items = append(items, item)
+			}
+		}
+
+		return items
+	case schemaTypeObject:
+		obj := make(map[string]interface{}, len(p.Properties))
+		keys := make([]string, 0, len(p.Properties))
+
+		for k := range p.Properties {
+			if !s.minimal || isRequired(p, k) {
+				keys = append(keys, k)
+			}
+		}
+
+		// random values are drawn in the same order for the same seed
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			if val := s.value(k, p.Properties[k]); val != nil {
+				obj[k] = val
+			}
+		}
+
+		return obj
+	}
+
+	return nil
+}
+
+// exampleFits: checks if example of `p` is set and is a valid value of its type
+func exampleFits(p *schemaJSONProperty) bool {
+	switch v := p.Example.(type) {
+	case float64:
+		return p.GetType() == schemaTypeNumber || (p.GetType() == schemaTypeInt && v == math.Trunc(v))
+	case string:
+		return p.GetType() == schemaTypeString
+	case bool:
+		return p.GetType() == schemaTypeBoolean
+	case []interface{}:
+		return p.GetType() == schemaTypeArray
+	case map[string]interface{}:
+		return p.GetType() == schemaTypeObject
+	}
+
+	return false
+}
+
+// isRequired: checks if property `name` is required by object `p`
+func isRequired(p *schemaJSONProperty, name string) bool {
+	for _, r := range p.Required {
+		if r == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// intn: returns random number in [0, n) or 0 without `rand`
+func (s *sampler) intn(n int) int {
+	if s.rand == nil {
+		return 0
+	}
+
+	return s.rand.Intn(n)
+}
+
+// integer: synthesizes a sample integer of property `name`
+func (s *sampler) integer(name string) int {
+	switch {
+	case s.rand == nil:
+		return 1
+	case name == "id" || strings.HasSuffix(name, "_id"):
+		return 1 + s.rand.Intn(999999)
+	case name == "date" || name == "time" || strings.HasSuffix(name, "_date") || strings.HasSuffix(name, "_time"):
+		return 1500000000 + s.rand.Intn(100000000)
+	case name == "count" || strings.HasSuffix(name, "_count") || strings.HasPrefix(name, "count_"):
+		return s.rand.Intn(100)
+	}
+
+	return s.rand.Intn(1000)
+}
+
+// string: synthesizes a sample string of property `name` with schema `format`
+func (s *sampler) string(name, format string) string {
+	if s.rand == nil {
+		return "string"
+	}
+
+	word := sampleWords[s.rand.Intn(len(sampleWords))]
+
+	switch format {
+	case "uri", "url":
+		return fmt.Sprintf("https://vk.com/%s%d", word, s.rand.Intn(1000))
+	case "email":
+		return fmt.Sprintf("%s%d@example.com", word, s.rand.Intn(1000))
+	case "date-time":
+		return time.Unix(int64(1500000000+s.rand.Intn(100000000)), 0).UTC().Format(time.RFC3339)
+	}
+
+	switch {
+	case name == "first_name":
+		return sampleFirstNames[s.rand.Intn(len(sampleFirstNames))]
+	case name == "last_name":
+		return sampleLastNames[s.rand.Intn(len(sampleLastNames))]
+	case name == "screen_name" || name == "domain":
+		return fmt.Sprintf("id%d", 1+s.rand.Intn(999999))
+	case name == "url" || strings.HasSuffix(name, "_url") || strings.HasPrefix(name, "photo"):
+		return fmt.Sprintf("https://vk.com/%s%d", word, s.rand.Intn(1000))
+	case name == "email":
+		return fmt.Sprintf("%s%d@example.com", word, s.rand.Intn(1000))
+	}
+
+	words := make([]string, 1+s.rand.Intn(4))
+
+	for i := range words {
+		words[i] = sampleWords[s.rand.Intn(len(sampleWords))]
+	}
+
+	return strings.Join(words, " ")
+}
+
+// qualifyRef: returns `ref` with the schema file it points to,
+// local references (`#/definitions/<name>`) point to objects schema
+func qualifyRef(ref string) string {
+	if strings.HasPrefix(ref, "responses.json") {
+		return responsesRefPrefix + nameFRef(ref)
+	}
+
+	return objectsRefPrefix + nameFRef(ref)
+}
+
+// resolveRef: returns definition referenced by `ref` from objects or responses schema
+func resolveRef(ref string) *schemaJSONProperty {
+	name := nameFRef(ref)
+
+	if strings.HasPrefix(qualifyRef(ref), responsesRefPrefix) {
+		if responsesGlobal == nil {
+			return nil
+		}
+
+		if def, ok := responsesGlobal.Definitions[name]; ok {
+			return def.Properties["response"]
+		}
+
+		return nil
+	}
+
+	if objectsGlobal == nil {
+		return nil
+	}
+
+	if def, ok := objectsGlobal.Definitions[name]; ok {
+		return &def
+	}
+
+	return nil
+}
@@ -0,0 +1,133 @@
+/*
+Copyright 2019 Konstantin Vasilev (burmuley@gmail.com)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"testing"
+)
+
+func Test_sampleResponse(t *testing.T) {
+	objects := `{"definitions": {
+		"base_bool_int": {"type": "integer", "enum": [0, 1]},
+		"users_user": {"type": "object", "properties": {
+			"id": {"type": "integer", "example": 1},
+			"name": {"type": "string", "example": "Pavel"},
+			"site": {"type": "string", "format": "uri"},
+			"verified": {"$ref": "#/definitions/base_bool_int"}
+		}},
+		"comment": {"type": "object", "properties": {
+			"text": {"type": "string"},
+			"thread": {"type": "array", "items": {"$ref": "#/definitions/comment"}}
+		}}
+	}}`
+	responses := `{"definitions": {
+		"users_get_response": {"type": "object", "properties": {"response": {"type": "array", "items": {"$ref": "objects.json#/definitions/users_user"}}}},
+		"ok_response": {"type": "object", "properties": {"response": {"type": "integer", "enum": [1]}}},
+		"user_response": {"type": "object", "properties": {"response": {"allOf": [
+			{"$ref": "objects.json#/definitions/users_user"},
+			{"type": "object", "properties": {"online": {"type": "boolean", "example": "yes"}}}
+		]}}},
+		"comment_response": {"type": "object", "properties": {"response": {"$ref": "objects.json#/definitions/comment"}}}
+	}}`
+
+	objectsGlobal, responsesGlobal = &objectsSchema{}, &responsesSchema{}
+
+	if err := json.Unmarshal([]byte(objects), objectsGlobal); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := json.Unmarshal([]byte(responses), responsesGlobal); err != nil {
+		t.Fatal(err)
+	}
+
+	defer func() { objectsGlobal, responsesGlobal = nil, nil }()
+
+	user := `\{"id":1,"name":"Pavel","site":"https://vk\.com/[a-z]+\d+","verified":[01]\}`
+
+	// `want` is a regular expression, values are random except enums and examples
+	tests := []struct {
+		name     string
+		response string
+		want     string
+	}{
+		{"TestArrayOfObjects", "users_get_response", `^\[` + user + `(,` + user + `){0,2}\]$`},
+		{"TestEnum", "ok_response", `^1$`},
+		{"TestAllOf", "user_response", `^\{"id":1,"name":"Pavel","online":(true|false),"site":"[^"]+","verified":[01]\}$`},
+		{"TestRecursion", "comment_response", `^\{"text":"[a-z ]+","thread":\[\]\}$`},
+		{"TestUnknown", "unknown_response", `^null$`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := schemaMethod{Name: "users.get"}
+			m.Responses.Response = &schemaMethodItem{Ref: "responses.json#/definitions/" + tt.response}
+			got := sampleResponse(m)
+
+			if !regexp.MustCompile(tt.want).MatchString(got) {
+				t.Errorf("sampleResponse() = %s, want %s", got, tt.want)
+			}
+
+			if again := sampleResponse(m); again != got {
+				t.Errorf("sampleResponse() = %s, want the same sample %s", again, got)
+			}
+		})
+	}
+}
+
+func Test_sampler(t *testing.T) {
+	user := &schemaJSONProperty{}
+	schema := `{"type": "object", "required": ["id"], "properties": {
+		"id": {"type": "integer"},
+		"first_name": {"type": "string"},
+		"sex": {"type": "integer", "enum": [0, 1, 2]},
+		"photos": {"type": "array", "items": {"type": "string"}}
+	}}`
+
+	if err := json.Unmarshal([]byte(schema), user); err != nil {
+		t.Fatal(err)
+	}
+
+	sample := func(seed int64, minimal bool) string {
+		b, _ := json.Marshal(newSampler(seed, minimal).value("", user))
+		return string(b)
+	}
+
+	tests := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{"TestSameSeed", sample(1, false), sample(1, false)},
+		{"TestMinimal", sample(1, true), `{"id":` + fmt.Sprint(newSampler(1, true).integer("id")) + `}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.want {
+				t.Errorf("sample = %s, want %s", tt.got, tt.want)
+			}
+		})
+	}
+
+	var maximal map[string]interface{}
+
+	if err := json.Unmarshal([]byte(sample(1, false)), &maximal); err != nil || len(maximal) != len(user.Properties) {
+		t.Errorf("maximal sample = %v, want all %d properties", maximal, len(user.Properties))
+	}
+}
@@ -35,7 +35,8 @@ type schemaPrefixList map[string]templateImports
 // JSON schema `type` field wrapper
 //////////////////////////////////////////////////////////////////////
 type schemaTypeWrapper struct {
-    Type string `json:"-"`
+    Type  string   `json:"-"`
+    Types []string `json:"-"` // all types if the schema defines several of them
 }
 
 func (s schemaTypeWrapper) String() string {
@@ -54,6 +55,10 @@ func (s *schemaTypeWrapper) UnmarshalJSON(b []byte) error {
         s.Type = fmt.Sprintf("%s", tmp)
     case []interface{}:
         s.Type = schemaTypeNumber
+
+        for _, t := range tmp.([]interface{}) {
+            s.Types = append(s.Types, fmt.Sprint(t))
+        }
     default:
         s.Type = schemaTypeUnknown
         return schemaError{
@@ -123,6 +128,8 @@ type schemaJSONProperty struct {
     Required    []string                       `json:"required,omitempty"`
     Enum        []interface{}                  `json:"enum,omitempty"` // TODO: make a wrapper (can be int or string)
     EnumNames   []string                       `json:"enum_names,omitempty"`
+    Format      string                         `json:"format,omitempty"`
+    Example     interface{}                    `json:"example,omitempty"`
     Items       *schemaItemsWrapper            `json:"items,omitempty"`
     Ref         string                         `json:"$ref,omitempty"`
     stripPrefix bool                           `json:"-"`
@@ -39,6 +39,7 @@ type schemaMethod struct {
         ExtResponse *schemaMethodItem `json:"extendedResponse"`
     } `json:"responses"`
     Errors []*schemaApiError
+    Pager  *methodPager `json:"-"`
 }
 
 func (s schemaMethod) Render(tmpl *template.Template) ([]byte, error) {
@@ -99,6 +100,23 @@ func (s schemaMethod) IsExtended() bool {
     return s.Responses.ExtResponse != nil
 }
 
+// IsAllowedFor: checks if the method can be called with `tokenType` access token.
+// Methods marked as `open` can be called with any token.
+func (s schemaMethod) IsAllowedFor(tokenType string) bool {
+    for _, v := range s.AccessTokens {
+        if v == tokenType || v == tokenTypeOpen {
+            return true
+        }
+    }
+
+    return false
+}
+
+// GetPager: returns pagination of the method results or nil if results are not paginated
+func (s schemaMethod) GetPager() *methodPager {
+    return s.Pager
+}
+
 // Data structure implements method parameter and response
 // Implements interfaces: IMethodItem, IType
 type schemaMethodItem struct {
@@ -110,6 +128,7 @@ type schemaMethodItem struct {
     EnumNames []string          `json:"enumNames"`
     Items     *schemaMethodItem `json:"items"`
     Ref       string            `json:"$ref"`
+    Maximum   int               `json:"maximum"`
 }
 
 func (s schemaMethodItem) GetGoType() string {
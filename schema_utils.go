@@ -34,16 +34,39 @@ import (
 // After `bCh` is closed it awaits for a header contents from `hCh` and concats it with
 // the body generated before. After this everything is dumped to the file.
 func bufWriter(wg *sync.WaitGroup, bCh, hCh chan []byte, prefix, outDir string) {
-	var (
-		f          *os.File
-		err        error
-		bBuf, hBuf bytes.Buffer
-	)
+	var bBuf, hBuf bytes.Buffer
 
 	defer wg.Done()
 
 	fName := filepath.Join(outputDirName, outDir, fmt.Sprintf("%s.go", prefix))
 
+	// listen for body and header channels
+	for {
+		body, bOk := <-bCh
+		bBuf.Write(body)
+
+		if !bOk {
+			break
+		}
+	}
+
+	header := <-hCh
+
+	// Add header on top of body buffer
+	hBuf.Write(header)
+	hBuf.Write(bBuf.Bytes())
+
+	writeCode(fName, hBuf.Bytes())
+}
+
+// writeCode: formats Go code `bb` and writes it to a file named `fName`.
+// If a target file exists it will be replaced. If the code can't be formatted it is written as is.
+func writeCode(fName string, bb []byte) {
+	var (
+		f   *os.File
+		err error
+	)
+
 	// Check if a target file exists and remove it if so
 	if checkFileExists(fName) {
 		if err := os.Remove(fName); err != nil {
@@ -62,24 +85,6 @@ func bufWriter(wg *sync.WaitGroup, bCh, hCh chan []byte, prefix, outDir string)
 
 	defer f.Close()
 
-	// listen for body and header channels
-	for {
-		body, bOk := <-bCh
-		bBuf.Write(body)
-
-		if !bOk {
-			break
-		}
-	}
-
-	header := <-hCh
-
-	// Add header on top of body buffer
-	hBuf.Write(header)
-	hBuf.Write(bBuf.Bytes())
-
-	bb := hBuf.Bytes()
-
 	// Format code && write to the file
 	if fmtCode, err := format.Source(bb); err != nil {
 		log.Printf("[[%s]] error formatting code: %s. Writing code as is...", fName, err)
@@ -99,6 +104,19 @@ func bufWriter(wg *sync.WaitGroup, bCh, hCh chan []byte, prefix, outDir string)
 
 }
 
+// renderFile: renders template `tmpl` with `data` and writes formatted result
+// to a file named `fName` in directory `outDir`
+func renderFile(tmpl *template.Template, data interface{}, outDir, fName string) error {
+	var buf bytes.Buffer
+
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return err
+	}
+
+	writeCode(filepath.Join(outputDirName, outDir, fName), buf.Bytes())
+
+	return nil
+}
 
 // generateItems: walks againt `items` and runs rendering of each item
 // sending rendered contents to an appropriate channel
@@ -175,6 +193,27 @@ func IsMultiple(t IType) bool {
 	return t.GetType() == schemaTypeMultiple
 }
 
+// paramEncoder: returns name of a typed encoder function from the SDK for a method parameter
+// or an empty string if the parameter should be encoded at request time
+func paramEncoder(t IType) string {
+	switch t.GetGoType() {
+	case "int":
+		return "EncodeInt"
+	case "int64":
+		return "EncodeInt64"
+	case "json.Number":
+		return "EncodeNumber"
+	case "bool":
+		return "EncodeBool"
+	case "[]string":
+		return "EncodeStrings"
+	case "[]int":
+		return "EncodeInts"
+	}
+
+	return ""
+}
+
 // fullFuncs: returns a map of functions to be passed to a text template renderer
 func fillFuncs(m map[string]interface{}) map[string]interface{} {
 	m["IsString"] = IsString
@@ -188,5 +227,6 @@ func fillFuncs(m map[string]interface{}) map[string]interface{} {
 	m["IsMultiple"] = IsMultiple
 	m["checkChars"] = checkChars
 	m["convertName"] = convertName
+	m["paramEncoder"] = paramEncoder
 	return m
 }
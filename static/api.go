@@ -16,95 +16,191 @@ limitations under the License.
 package go_vkapi
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"github.com/Burmuley/go-vkapi/responses"
-	"io/ioutil"
 	"net/http"
 	"net/url"
 	"os"
+	"strings"
+
+	"github.com/Burmuley/go-vkapi/internal/version"
 )
 
 const (
-	apiVersion = "5.101"
-	apiUrl     = "https://api.vk.com/method/"
+	apiUrl = "https://api.vk.com/method/"
+
+	// Maximum number of times a request is resent (after solving captcha, passing validation or with another token)
+	maxRetryAttempts = 3
 )
 
 type VKApi struct {
-	userToken  string
+	tokens     TokenSource
+	tokenType  string
 	apiVersion string
+	httpClient *http.Client
 	apiUrl     string
+	batcher    *autoBatcher
+	captcha    CaptchaSolver
+	validation ValidationHandler
+
+	middlewares []Middleware
+	handler     Handler
+
+	unknownFields *UnknownFields
+}
+
+// Option configures optional VKApi features
+type Option func(vk *VKApi)
+
+// WithApiUrl sets base URL of VK API methods (`https://api.vk.com/method/` by default)
+func WithApiUrl(apiUrl string) Option {
+	return func(vk *VKApi) {
+		vk.apiUrl = apiUrl
+	}
+}
+
+// WithHTTPClient sets HTTP client used to send requests (`http.DefaultClient` by default)
+func WithHTTPClient(client *http.Client) Option {
+	return func(vk *VKApi) {
+		vk.httpClient = client
+	}
 }
 
 // SendAPIRequest calls defined method of the VK API with the defined parameters
 // Returns slice of bytes with API response
 func (vk *VKApi) SendAPIRequest(method string, parameters map[string]interface{}) ([]byte, error) {
+	return vk.SendAPIRequestContext(context.Background(), method, parameters)
+}
+
+// SendAPIRequestContext is the same as SendAPIRequest with context `ctx` controlling the request
+func (vk *VKApi) SendAPIRequestContext(ctx context.Context, method string, parameters map[string]interface{}) ([]byte, error) {
+	if vk.batcher != nil && isBatchable(method) {
+		return vk.batcher.send(ctx, method, parameters)
+	}
+
+	return vk.sendAPIRequestDirect(ctx, method, parameters)
+}
+
+// sendAPIRequestDirect calls defined method of the VK API bypassing auto batching
+func (vk *VKApi) sendAPIRequestDirect(ctx context.Context, method string, parameters map[string]interface{}) ([]byte, error) {
+	apiResp, err := vk.sendRequest(ctx, method, parameters)
+
+	if err != nil {
+		return []byte{}, err
+	}
+
+	return apiResp.Raw, nil
+}
+
+// sendRequest calls defined method of the VK API with the defined parameters passing it through the middleware chain.
+// Returns whole API response including additional fields like `execute_errors`
+func (vk *VKApi) sendRequest(ctx context.Context, method string, parameters map[string]interface{}) (*Response, error) {
+	return vk.handler(ctx, &Request{Method: method, Params: parameters, TokenType: vk.tokenType})
+}
+
+// transport sends one HTTP request to the VK API and decodes its response, it's the innermost handler of the chain
+func (vk *VKApi) transport(ctx context.Context, r *Request) (*Response, error) {
 	//Format API endpoint
-	u, err := url.Parse(apiUrl + method)
+	u, err := url.Parse(vk.apiUrl + r.Method)
 
 	if err != nil {
 		return nil, err
 	}
 
-	//Fill mandatory parameters
-	parameters["access_token"] = vk.userToken
-	parameters["v"] = apiVersion
-
 	// Format URL-encoded key-value parameters
 	request := url.Values{}
-	for k, v := range parameters {
-		switch v.(type) {
-		case string:
-			request.Add(k, v.(string))
-		default:
-			request.Add(k, fmt.Sprint(v))
+	for k, v := range r.Params {
+		value, err := EncodeParam(v)
+
+		if err != nil {
+			return nil, fmt.Errorf("parameter `%s`: %w", k, err)
 		}
+
+		request.Add(k, value)
 	}
 
+	//Fill mandatory parameters
+	request.Set("access_token", r.Token)
+	request.Set("v", vk.apiVersion)
+
 	// Send request and read response
-	resp, err := http.PostForm(u.String(), request)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), strings.NewReader(request.Encode()))
 
 	if err != nil {
-		return []byte{}, err
+		return nil, err
 	}
 
-	defer resp.Body.Close()
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 
-	//Read response body and check for errors
-	rBody, err := ioutil.ReadAll(resp.Body)
+	resp, err := vk.httpClient.Do(req)
 
 	if err != nil {
-		return []byte{}, err
+		return nil, err
 	}
 
-	var apiResp responses.ApiRawResponse
+	defer resp.Body.Close()
 
-	if err := json.Unmarshal(rBody, &apiResp); err != nil {
-		return []byte{}, err
-	}
+	// Decode response body in a single pass
+	return decodeResponse(resp.Body, r.Target)
+}
+
+func (vk *VKApi) SendObjRequest(method string, params map[string]interface{}, object interface{}) error {
+	return vk.SendObjRequestContext(context.Background(), method, params, object)
+}
+
+// SendObjRequestContext is the same as SendObjRequest with context `ctx` controlling the request
+func (vk *VKApi) SendObjRequestContext(ctx context.Context, method string, params map[string]interface{}, object interface{}) error {
+	target := object
+	var strict *strictTarget
 
-	if apiResp.Error.GetCode() != 0 {
-		return []byte{}, apiResp.Error
+	if vk.unknownFields != nil {
+		strict = &strictTarget{target: object}
+		target = strict
 	}
 
-	return apiResp.Response, nil
-}
+	if vk.batcher != nil && isBatchable(method) {
+		// Auto batched requests return raw bytes of the response
+		info, err := vk.SendAPIRequestContext(ctx, method, params)
 
-func (vk *VKApi) SendObjRequest(method string, params map[string]interface{}, object interface{}) error {
-	info, err := vk.SendAPIRequest(method, params)
+		if err != nil {
+			return err
+		}
 
-	if err != nil {
-		return err
+		if err := json.Unmarshal(info, target); err != nil {
+			return err
+		}
+	} else {
+		apiResp, err := vk.handler(ctx, &Request{Method: method, Params: params, TokenType: vk.tokenType, Target: target})
+
+		if err != nil {
+			return err
+		}
+
+		if !apiResp.Decoded {
+			if err := json.Unmarshal(apiResp.Raw, target); err != nil {
+				return err
+			}
+		}
 	}
 
-	if err := json.Unmarshal(info, &object); err != nil {
-		return err
+	// only responses with unknown fields are walked to find all of them
+	if strict != nil && strict.mismatch != nil {
+		vk.unknownFields.inspect(method, strict.mismatch, object)
 	}
 
 	return nil
 }
 
-func NewApiWithToken(token string) *VKApi {
+// NewApiWithToken creates VKApi using `token` to authorize requests.
+// Optional features can be enabled with `opts`.
+func NewApiWithToken(token string, opts ...Option) *VKApi {
+	return NewApiWithTokenSource(StaticTokenSource(token), opts...)
+}
+
+// NewApiWithTokenSource creates VKApi taking a token to authorize each request from `tokens`.
+// Optional features can be enabled with `opts`.
+func NewApiWithTokenSource(tokens TokenSource, opts ...Option) *VKApi {
 	envApiUrl := os.Getenv("VK_API_URL")
 	locApiUrl := apiUrl
 
@@ -112,8 +208,17 @@ func NewApiWithToken(token string) *VKApi {
 		locApiUrl = envApiUrl
 	}
 
-	return &VKApi{userToken: token,
-		apiVersion: apiVersion,
+	vk := &VKApi{tokens: tokens,
+		apiVersion: version.API,
 		apiUrl:     locApiUrl,
+		httpClient: http.DefaultClient,
 	}
+
+	for _, opt := range opts {
+		opt(vk)
+	}
+
+	vk.handler = vk.buildHandler()
+
+	return vk
 }
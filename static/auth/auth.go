@@ -0,0 +1,300 @@
+/*
+Copyright 2019 Konstantin Vasilev (burmuley@gmail.com)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package auth implements helpers for VK authorization flows (https://vk.com/dev/access_token):
+// implicit flow, authorization code flow and client credentials flow.
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Burmuley/go-vkapi/internal/version"
+)
+
+const (
+	oauthUrl = "https://oauth.vk.com/"
+
+	// RedirectBlank is the redirect URI for standalone applications using implicit flow
+	RedirectBlank = "https://oauth.vk.com/blank.html"
+)
+
+// Config describes VK application used to obtain access tokens
+type Config struct {
+	ClientId     int          // application ID
+	ClientSecret string       // application secret key (required for code exchange and service token)
+	RedirectUri  string       // redirect URI registered for the application
+	Display      string       // authorization page type: `page`, `popup` or `mobile`
+	OAuthUrl     string       // OAuth server URL, `https://oauth.vk.com/` if empty
+	HTTPClient   *http.Client // client to call OAuth server, `http.DefaultClient` if nil
+}
+
+// Token holds the result of authorization
+type Token struct {
+	AccessToken string         // user or service access token
+	GroupTokens map[int]string // community access tokens by community ID (community authorization)
+	ExpiresIn   int            // token lifetime in seconds, `0` if the token never expires
+	Expiry      time.Time      // token expiration time, zero if the token never expires
+	UserId      int            // ID of the authorized user
+	Email       string         // user e-mail, if `email` scope was requested and user allowed it
+	State       string         // `state` passed to the authorization URL (implicit flow only)
+}
+
+// Expired checks if the token has expired
+func (t *Token) Expired() bool {
+	return !t.Expiry.IsZero() && time.Now().After(t.Expiry)
+}
+
+// setExpiry fills `Expiry` from `ExpiresIn` counting from `now`
+func (t *Token) setExpiry(now time.Time) {
+	if t.ExpiresIn > 0 {
+		t.Expiry = now.Add(time.Duration(t.ExpiresIn) * time.Second)
+	}
+}
+
+// Error represents an error returned by OAuth server
+type Error struct {
+	Code        string `json:"error"`
+	Description string `json:"error_description"`
+}
+
+func (e Error) Error() string {
+	return fmt.Sprintf("OAUTH ERROR! Code: %s, Description: %s", e.Code, e.Description)
+}
+
+// ImplicitFlowURL returns URL of the authorization page for implicit flow.
+// Access token is passed in the fragment of the redirect URI, see `ParseRedirectFragment`.
+// Pass `groupIds` to request community tokens.
+func (c *Config) ImplicitFlowURL(scope Scope, state string, groupIds ...int) string {
+	return c.authorizeURL("token", scope, state, groupIds)
+}
+
+// CodeFlowURL returns URL of the authorization page for authorization code flow.
+// The code is passed in `code` query parameter of the redirect URI, see `Exchange`.
+// Pass `groupIds` to request community tokens.
+func (c *Config) CodeFlowURL(scope Scope, state string, groupIds ...int) string {
+	return c.authorizeURL("code", scope, state, groupIds)
+}
+
+func (c *Config) authorizeURL(responseType string, scope Scope, state string, groupIds []int) string {
+	params := url.Values{}
+	params.Set("client_id", strconv.Itoa(c.ClientId))
+	params.Set("redirect_uri", c.RedirectUri)
+	params.Set("response_type", responseType)
+	params.Set("v", version.API)
+
+	if scope != nil {
+		params.Set("scope", strconv.Itoa(scope.Mask()))
+	}
+
+	if state != "" {
+		params.Set("state", state)
+	}
+
+	if c.Display != "" {
+		params.Set("display", c.Display)
+	}
+
+	if len(groupIds) > 0 {
+		ids := make([]string, len(groupIds))
+
+		for k, v := range groupIds {
+			ids[k] = strconv.Itoa(v)
+		}
+
+		params.Set("group_ids", strings.Join(ids, ","))
+	}
+
+	return c.oauthURL() + "authorize?" + params.Encode()
+}
+
+// Exchange exchanges authorization `code` for an access token
+func (c *Config) Exchange(ctx context.Context, code string) (*Token, error) {
+	params := url.Values{}
+	params.Set("client_id", strconv.Itoa(c.ClientId))
+	params.Set("client_secret", c.ClientSecret)
+	params.Set("redirect_uri", c.RedirectUri)
+	params.Set("code", code)
+	params.Set("v", version.API)
+
+	return c.requestToken(ctx, params)
+}
+
+// ServiceToken obtains a service access token using client credentials flow
+func (c *Config) ServiceToken(ctx context.Context) (*Token, error) {
+	params := url.Values{}
+	params.Set("client_id", strconv.Itoa(c.ClientId))
+	params.Set("client_secret", c.ClientSecret)
+	params.Set("grant_type", "client_credentials")
+	params.Set("v", version.API)
+
+	return c.requestToken(ctx, params)
+}
+
+// requestToken calls `access_token` endpoint of OAuth server with `params`
+func (c *Config) requestToken(ctx context.Context, params url.Values) (*Token, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.oauthURL()+"access_token?"+params.Encode(), nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	client := c.HTTPClient
+
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var oErr Error
+
+	if err := json.Unmarshal(body, &oErr); err == nil && oErr.Code != "" {
+		return nil, oErr
+	}
+
+	var fields map[string]json.RawMessage
+
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return nil, err
+	}
+
+	token := &Token{}
+
+	for k, v := range fields {
+		var err error
+
+		switch {
+		case k == "access_token":
+			err = json.Unmarshal(v, &token.AccessToken)
+		case k == "expires_in":
+			err = json.Unmarshal(v, &token.ExpiresIn)
+		case k == "user_id":
+			err = json.Unmarshal(v, &token.UserId)
+		case k == "email":
+			err = json.Unmarshal(v, &token.Email)
+		case strings.HasPrefix(k, "access_token_"):
+			var gToken string
+
+			if err = json.Unmarshal(v, &gToken); err == nil {
+				err = token.addGroupToken(k, gToken)
+			}
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid `%s` in token response: %s", k, err)
+		}
+	}
+
+	token.setExpiry(time.Now())
+
+	return token, nil
+}
+
+// ParseRedirectFragment parses the redirect URI of implicit flow (or just its fragment) and returns the token.
+// If user denied access, the returned error is `Error`.
+func ParseRedirectFragment(redirectUri string) (*Token, error) {
+	fragment := redirectUri
+
+	if i := strings.Index(redirectUri, "#"); i >= 0 {
+		fragment = redirectUri[i+1:]
+	}
+
+	values, err := url.ParseQuery(fragment)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if values.Get("error") != "" {
+		return nil, Error{Code: values.Get("error"), Description: values.Get("error_description")}
+	}
+
+	token := &Token{
+		AccessToken: values.Get("access_token"),
+		Email:       values.Get("email"),
+		State:       values.Get("state"),
+	}
+
+	for k, v := range values {
+		var err error
+
+		switch {
+		case k == "expires_in":
+			token.ExpiresIn, err = strconv.Atoi(v[0])
+		case k == "user_id":
+			token.UserId, err = strconv.Atoi(v[0])
+		case strings.HasPrefix(k, "access_token_"):
+			err = token.addGroupToken(k, v[0])
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid `%s` in redirect URI: %s", k, err)
+		}
+	}
+
+	if token.AccessToken == "" && len(token.GroupTokens) == 0 {
+		return nil, fmt.Errorf("no access token in redirect URI")
+	}
+
+	token.setExpiry(time.Now())
+
+	return token, nil
+}
+
+// addGroupToken adds community token from `access_token_<group ID>` field
+func (t *Token) addGroupToken(field, token string) error {
+	id, err := strconv.Atoi(strings.TrimPrefix(field, "access_token_"))
+
+	if err != nil {
+		return err
+	}
+
+	if t.GroupTokens == nil {
+		t.GroupTokens = make(map[int]string)
+	}
+
+	t.GroupTokens[id] = token
+
+	return nil
+}
+
+func (c *Config) oauthURL() string {
+	if c.OAuthUrl == "" {
+		return oauthUrl
+	}
+
+	return c.OAuthUrl
+}
@@ -0,0 +1,208 @@
+/*
+Copyright 2019 Konstantin Vasilev (burmuley@gmail.com)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package auth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/Burmuley/go-vkapi/internal/version"
+)
+
+// newOAuthServer starts a local stand-in for `oauth.vk.com`
+func newOAuthServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+
+		if r.URL.Path != "/access_token" || q.Get("client_id") != "42" || q.Get("client_secret") != "secret" || q.Get("v") != version.API {
+			fmt.Fprint(w, `{"error":"invalid_client","error_description":"client_secret is incorrect"}`)
+			return
+		}
+
+		switch {
+		case q.Get("grant_type") == "client_credentials":
+			fmt.Fprint(w, `{"access_token":"service-token","expires_in":0}`)
+		case q.Get("code") == "user-code" && q.Get("redirect_uri") == "https://example.com/cb":
+			fmt.Fprint(w, `{"access_token":"user-token","expires_in":86400,"user_id":66748,"email":"user@example.com"}`)
+		case q.Get("code") == "group-code":
+			fmt.Fprint(w, `{"access_token_123":"group-token","expires_in":0}`)
+		default:
+			fmt.Fprint(w, `{"error":"invalid_grant","error_description":"Code is invalid or expired."}`)
+		}
+	}))
+}
+
+func TestConfig_FlowURLs(t *testing.T) {
+	c := &Config{ClientId: 42, RedirectUri: RedirectBlank, Display: "page"}
+
+	tests := []struct {
+		name string
+		url  string
+		want url.Values
+	}{
+		{
+			"TestImplicitFlow",
+			c.ImplicitFlowURL(UserScopeFriends|UserScopeOffline, "xyz"),
+			url.Values{
+				"client_id":     {"42"},
+				"redirect_uri":  {RedirectBlank},
+				"response_type": {"token"},
+				"scope":         {"65538"},
+				"state":         {"xyz"},
+				"display":       {"page"},
+				"v":             {version.API},
+			},
+		},
+		{
+			"TestCodeFlowGroups",
+			c.CodeFlowURL(GroupScopeMessages|GroupScopeManage, "", 1, 2),
+			url.Values{
+				"client_id":     {"42"},
+				"redirect_uri":  {RedirectBlank},
+				"response_type": {"code"},
+				"scope":         {"266240"},
+				"group_ids":     {"1,2"},
+				"display":       {"page"},
+				"v":             {version.API},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := url.Parse(tt.url)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if u.Host != "oauth.vk.com" || u.Path != "/authorize" {
+				t.Errorf("unexpected authorization URL %s", tt.url)
+			}
+
+			if got := u.Query(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("query = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfig_Exchange(t *testing.T) {
+	srv := newOAuthServer()
+	defer srv.Close()
+
+	c := &Config{ClientId: 42, ClientSecret: "secret", RedirectUri: "https://example.com/cb", OAuthUrl: srv.URL + "/"}
+
+	t.Run("TestUserToken", func(t *testing.T) {
+		token, err := c.Exchange(context.Background(), "user-code")
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if token.AccessToken != "user-token" || token.UserId != 66748 || token.Email != "user@example.com" || token.Expiry.IsZero() || token.Expired() {
+			t.Errorf("Exchange() = %+v", token)
+		}
+	})
+
+	t.Run("TestGroupToken", func(t *testing.T) {
+		token, err := c.Exchange(context.Background(), "group-code")
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if token.GroupTokens[123] != "group-token" || !token.Expiry.IsZero() {
+			t.Errorf("Exchange() = %+v", token)
+		}
+	})
+
+	t.Run("TestInvalidCode", func(t *testing.T) {
+		_, err := c.Exchange(context.Background(), "bad-code")
+
+		if oErr, ok := err.(Error); !ok || oErr.Code != "invalid_grant" {
+			t.Errorf("Exchange() error = %v, want invalid_grant", err)
+		}
+	})
+}
+
+func TestConfig_ServiceToken(t *testing.T) {
+	srv := newOAuthServer()
+	defer srv.Close()
+
+	c := &Config{ClientId: 42, ClientSecret: "secret", OAuthUrl: srv.URL + "/"}
+	token, err := c.ServiceToken(context.Background())
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if token.AccessToken != "service-token" || !token.Expiry.IsZero() {
+		t.Errorf("ServiceToken() = %+v", token)
+	}
+
+	c.ClientSecret = "wrong"
+
+	if _, err := c.ServiceToken(context.Background()); err == nil {
+		t.Errorf("ServiceToken() error = <nil>, want invalid_client")
+	}
+}
+
+func TestParseRedirectFragment(t *testing.T) {
+	tests := []struct {
+		name    string
+		uri     string
+		want    Token
+		wantErr bool
+	}{
+		{
+			"TestUserToken",
+			"https://oauth.vk.com/blank.html#access_token=abc&expires_in=0&user_id=1&email=a@b.c&state=xyz",
+			Token{AccessToken: "abc", UserId: 1, Email: "a@b.c", State: "xyz"},
+			false,
+		},
+		{
+			"TestGroupTokens",
+			"access_token_1=abc&access_token_2=def&expires_in=0",
+			Token{GroupTokens: map[int]string{1: "abc", 2: "def"}},
+			false,
+		},
+		{
+			"TestAccessDenied",
+			"https://oauth.vk.com/blank.html#error=access_denied&error_description=User+denied+your+request",
+			Token{},
+			true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseRedirectFragment(tt.uri)
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseRedirectFragment() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if !tt.wantErr && !reflect.DeepEqual(*got, tt.want) {
+				t.Errorf("ParseRedirectFragment() got = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+/*
+Copyright 2019 Konstantin Vasilev (burmuley@gmail.com)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package auth
+
+// Scope is a set of access permissions requested for a token
+type Scope interface {
+	Mask() int
+}
+
+// UserScope is a set of access permissions for a user token (https://vk.com/dev/permissions)
+type UserScope int
+
+// User token permissions, combine them with `|`
+const (
+	UserScopeNotify        UserScope = 1 << 0
+	UserScopeFriends       UserScope = 1 << 1
+	UserScopePhotos        UserScope = 1 << 2
+	UserScopeAudio         UserScope = 1 << 3
+	UserScopeVideo         UserScope = 1 << 4
+	UserScopeStories       UserScope = 1 << 6
+	UserScopePages         UserScope = 1 << 7
+	UserScopeMenu          UserScope = 1 << 8
+	UserScopeStatus        UserScope = 1 << 10
+	UserScopeNotes         UserScope = 1 << 11
+	UserScopeMessages      UserScope = 1 << 12
+	UserScopeWall          UserScope = 1 << 13
+	UserScopeAds           UserScope = 1 << 15
+	UserScopeOffline       UserScope = 1 << 16
+	UserScopeDocs          UserScope = 1 << 17
+	UserScopeGroups        UserScope = 1 << 18
+	UserScopeNotifications UserScope = 1 << 19
+	UserScopeStats         UserScope = 1 << 20
+	UserScopeEmail         UserScope = 1 << 22
+	UserScopeMarket        UserScope = 1 << 27
+)
+
+// Mask returns bit mask of the permissions
+func (s UserScope) Mask() int {
+	return int(s)
+}
+
+// GroupScope is a set of access permissions for a community token (https://vk.com/dev/permissions)
+type GroupScope int
+
+// Community token permissions, combine them with `|`
+const (
+	GroupScopeStories   GroupScope = 1 << 0
+	GroupScopePhotos    GroupScope = 1 << 2
+	GroupScopeAppWidget GroupScope = 1 << 6
+	GroupScopeMessages  GroupScope = 1 << 12
+	GroupScopeDocs      GroupScope = 1 << 17
+	GroupScopeManage    GroupScope = 1 << 18
+)
+
+// Mask returns bit mask of the permissions
+func (s GroupScope) Mask() int {
+	return int(s)
+}
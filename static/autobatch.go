@@ -0,0 +1,191 @@
+/*
+Copyright 2019 Konstantin Vasilev (burmuley@gmail.com)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package go_vkapi
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Burmuley/go-vkapi/errors"
+)
+
+// Methods (or groups of methods if ends with `.`) which can't be called from `execute`
+var nonBatchableMethods = []string{
+	"execute",
+	"execute.",
+	"auth.",
+	"secure.",
+}
+
+// isBatchable checks if `method` can be called from `execute`
+func isBatchable(method string) bool {
+	for _, v := range nonBatchableMethods {
+		if method == v || (strings.HasSuffix(v, ".") && strings.HasPrefix(method, v)) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// WithAutoBatch enables transparent batching of concurrent requests.
+// Requests arriving within `window` after the first one (but no more than `MaxBatchSize`)
+// are sent in one `execute` request. Methods which can't be called from `execute` are sent directly.
+func WithAutoBatch(window time.Duration) Option {
+	return func(vk *VKApi) {
+		vk.batcher = &autoBatcher{vk: vk, window: window}
+	}
+}
+
+// autoCall is a request waiting to be sent by autoBatcher
+type autoCall struct {
+	batchCall
+	ctx    context.Context
+	result json.RawMessage
+	ready  chan struct{}
+}
+
+// autoBatcher collects concurrent requests and sends them in `execute` requests
+type autoBatcher struct {
+	vk      *VKApi
+	window  time.Duration
+	mu      sync.Mutex
+	pending []*autoCall
+	timer   *time.Timer
+}
+
+// send queues the request and waits for its result or `ctx` cancellation
+func (a *autoBatcher) send(ctx context.Context, method string, params map[string]interface{}) ([]byte, error) {
+	call := &autoCall{ctx: ctx, ready: make(chan struct{})}
+	call.method = method
+	call.params = params
+	call.decode = func(raw json.RawMessage) error {
+		call.result = raw
+		return nil
+	}
+
+	a.mu.Lock()
+	a.pending = append(a.pending, call)
+
+	if len(a.pending) >= MaxBatchSize {
+		calls := a.take()
+		a.mu.Unlock()
+		go a.flush(calls)
+	} else {
+		if a.timer == nil {
+			a.timer = time.AfterFunc(a.window, func() {
+				a.mu.Lock()
+				calls := a.take()
+				a.mu.Unlock()
+				a.flush(calls)
+			})
+		}
+
+		a.mu.Unlock()
+	}
+
+	select {
+	case <-call.ready:
+		if call.err != nil {
+			return []byte{}, call.err
+		}
+
+		return call.result, nil
+	case <-ctx.Done():
+		return []byte{}, ctx.Err()
+	}
+}
+
+// take returns pending calls and resets the batcher state, must be called with `mu` locked
+func (a *autoBatcher) take() []*autoCall {
+	calls := a.pending
+	a.pending = nil
+
+	if a.timer != nil {
+		a.timer.Stop()
+		a.timer = nil
+	}
+
+	return calls
+}
+
+// flush sends `calls` and notifies callers. Calls cancelled by callers before sending are dropped.
+// An error of the `execute` request is returned to every call of the batch.
+func (a *autoBatcher) flush(calls []*autoCall) {
+	active := make([]*autoCall, 0, len(calls))
+
+	for _, c := range calls {
+		if err := c.ctx.Err(); err != nil {
+			c.err = err
+			close(c.ready)
+			continue
+		}
+
+		active = append(active, c)
+	}
+
+	switch len(active) {
+	case 0:
+		return
+	case 1:
+		// no need in `execute` for a single call
+		c := active[0]
+		c.result, c.err = a.vk.sendAPIRequestDirect(c.ctx, c.method, c.params)
+		close(c.ready)
+		return
+	}
+
+	// the request is cancelled only when all callers have gone
+	ctx, cancel := context.WithCancel(context.Background())
+	finished := make(chan struct{})
+
+	go func() {
+		for _, c := range active {
+			select {
+			case <-c.ctx.Done():
+			case <-finished:
+				return
+			}
+		}
+
+		cancel()
+	}()
+
+	bCalls := make([]*batchCall, len(active))
+
+	for k, c := range active {
+		bCalls[k] = &c.batchCall
+	}
+
+	err := a.vk.executeCalls(ctx, bCalls)
+	close(finished)
+	cancel()
+
+	for _, c := range active {
+		if err != nil {
+			// results can't be trusted when the request failed or its errors don't match the calls
+			c.result, c.err = nil, err
+		} else if e, ok := c.err.(errors.ExecuteError); ok {
+			// callers expect the same error as for a direct request
+			c.err = errors.ApiError{Code: e.Code, Message: e.Message}
+		}
+
+		close(c.ready)
+	}
+}
@@ -0,0 +1,120 @@
+/*
+Copyright 2019 Konstantin Vasilev (burmuley@gmail.com)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package go_vkapi
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func Test_autoBatcher(t *testing.T) {
+	var executeCalls, directCalls int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/execute":
+			atomic.AddInt32(&executeCalls, 1)
+			n := strings.Count(r.FormValue("code"), "API.")
+			results := make([]string, n)
+
+			for k := range results {
+				results[k] = fmt.Sprint(k)
+			}
+
+			if strings.Contains(r.FormValue("code"), "API.groups.get") {
+				// error of a call which succeeded according to results
+				fmt.Fprintf(w, `{"response":[%s],"execute_errors":[{"method":"groups.get","error_code":15,"error_msg":"Access denied"}]}`,
+					strings.Join(results, ","))
+				return
+			}
+
+			fmt.Fprintf(w, `{"response":[%s]}`, strings.Join(results, ","))
+		default:
+			atomic.AddInt32(&directCalls, 1)
+			fmt.Fprint(w, `{"response":100}`)
+		}
+	}))
+	defer srv.Close()
+
+	vk := NewApiWithToken("token", WithAutoBatch(50*time.Millisecond))
+	vk.apiUrl = srv.URL + "/"
+
+	t.Run("TestConcurrentCalls", func(t *testing.T) {
+		wg := sync.WaitGroup{}
+
+		for i := 0; i < 3; i++ {
+			wg.Add(1)
+
+			go func() {
+				defer wg.Done()
+
+				if _, err := vk.SendAPIRequestContext(context.Background(), "users.get", map[string]interface{}{}); err != nil {
+					t.Error(err)
+				}
+			}()
+		}
+
+		wg.Wait()
+
+		if atomic.LoadInt32(&executeCalls) != 1 || atomic.LoadInt32(&directCalls) != 0 {
+			t.Errorf("execute calls = %d, direct calls = %d, want 1 and 0", executeCalls, directCalls)
+		}
+	})
+
+	t.Run("TestUnmatchedExecuteErrors", func(t *testing.T) {
+		wg := sync.WaitGroup{}
+
+		for i := 0; i < 2; i++ {
+			wg.Add(1)
+
+			go func() {
+				defer wg.Done()
+
+				if resp, err := vk.SendAPIRequestContext(context.Background(), "groups.get", map[string]interface{}{}); err == nil {
+					t.Errorf("SendAPIRequestContext() = %s, want unmatched execute errors", resp)
+				}
+			}()
+		}
+
+		wg.Wait()
+	})
+
+	t.Run("TestNonBatchable", func(t *testing.T) {
+		if _, err := vk.SendAPIRequest("execute.myProcedure", map[string]interface{}{}); err != nil {
+			t.Error(err)
+		}
+
+		if atomic.LoadInt32(&directCalls) != 1 {
+			t.Errorf("direct calls = %d, want 1", directCalls)
+		}
+	})
+
+	t.Run("TestCancelledContext", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		if _, err := vk.SendAPIRequestContext(ctx, "users.get", map[string]interface{}{}); err != context.Canceled {
+			t.Errorf("SendAPIRequestContext() error = %v, want %v", err, context.Canceled)
+		}
+	})
+}
@@ -0,0 +1,274 @@
+/*
+Copyright 2019 Konstantin Vasilev (burmuley@gmail.com)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package go_vkapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// MaxBatchSize is the maximum number of API calls VK allows in one `execute` request
+const MaxBatchSize = 25
+
+// ErrNotExecuted is returned by `Future.Get` when the batch the call was queued to hasn't been executed yet
+var ErrNotExecuted = errors.New("batch is not executed yet")
+
+// batchCall represents a single API call queued to a Batch
+type batchCall struct {
+	method string
+	params map[string]interface{}
+	decode func(raw json.RawMessage) error
+	done   bool
+	err    error
+}
+
+// finish stores result of the call
+func (c *batchCall) finish(raw json.RawMessage, err error) {
+	c.done = true
+	c.err = err
+
+	if err == nil {
+		c.err = c.decode(raw)
+	}
+}
+
+// Batch collects API calls to send them in `execute` requests (up to `MaxBatchSize` calls per request).
+// Calls are queued with `<Method>Batch` variants of the generated methods.
+type Batch struct {
+	vk    *VKApi
+	calls []*batchCall
+}
+
+// Future holds a result of an API call queued to a Batch
+type Future[T any] struct {
+	call   *batchCall
+	result T
+}
+
+// Get returns result of the call. It returns `ErrNotExecuted` until the batch is executed.
+// If the call failed inside of `execute` the error is `errors.ExecuteError`.
+func (f *Future[T]) Get() (T, error) {
+	if !f.call.done {
+		return f.result, ErrNotExecuted
+	}
+
+	return f.result, f.call.err
+}
+
+// NewBatch creates a new empty Batch sending `execute` requests using `vk`
+func NewBatch(vk *VKApi) *Batch {
+	return &Batch{vk: vk}
+}
+
+// Len returns number of calls queued to the batch
+func (b *Batch) Len() int {
+	return len(b.calls)
+}
+
+// Execute sends all queued calls and distributes results to the futures.
+// Calls are sent in chunks of `MaxBatchSize`, one `execute` request per chunk.
+// Returned error is a request level error (network or the whole `execute` failure),
+// it is also set to every future of the failed chunk. An error is also returned when
+// `execute_errors` can't be matched to the failed calls. The batch is empty after execution.
+func (b *Batch) Execute(ctx context.Context) error {
+	calls := b.calls
+	b.calls = nil
+
+	var firstErr error
+
+	for len(calls) > 0 {
+		n := len(calls)
+
+		if n > MaxBatchSize {
+			n = MaxBatchSize
+		}
+
+		if err := b.vk.executeCalls(ctx, calls[:n]); err != nil && firstErr == nil {
+			firstErr = err
+		}
+
+		calls = calls[n:]
+	}
+
+	return firstErr
+}
+
+// executeCalls sends `calls` in one `execute` request and stores results to each call.
+// Errors from `execute_errors` are matched to the calls returned `false` in order.
+func (vk *VKApi) executeCalls(ctx context.Context, calls []*batchCall) error {
+	apiResp, err := vk.sendRequest(ctx, "execute", map[string]interface{}{"code": renderScript(calls)})
+
+	if err != nil {
+		for _, c := range calls {
+			c.finish(nil, err)
+		}
+
+		return err
+	}
+
+	var results []json.RawMessage
+
+	if err := json.Unmarshal(apiResp.Raw, &results); err != nil {
+		for _, c := range calls {
+			c.finish(nil, err)
+		}
+
+		return err
+	}
+
+	// failed calls return `false` in results, errors are listed in `execute_errors` in the same order
+	execErrors := apiResp.ExecuteErrors
+	var matchErr error
+
+	for k, c := range calls {
+		if k >= len(results) {
+			c.finish(nil, fmt.Errorf("no result for `%s` in execute response", c.method))
+			continue
+		}
+
+		if !bytes.Equal(results[k], []byte("false")) {
+			c.finish(results[k], nil)
+			continue
+		}
+
+		if len(execErrors) == 0 || execErrors[0].Method != c.method {
+			c.finish(nil, fmt.Errorf("no execute error matches failed `%s` call", c.method))
+			execErrors = nil
+
+			if matchErr == nil {
+				matchErr = fmt.Errorf("execute errors don't match failed calls: `%s` call failed without an error", c.method)
+			}
+
+			continue
+		}
+
+		c.finish(nil, execErrors[0])
+		execErrors = execErrors[1:]
+	}
+
+	if len(execErrors) > 0 && matchErr == nil {
+		matchErr = fmt.Errorf("execute errors don't match failed calls: %d errors left unmatched", len(execErrors))
+	}
+
+	return matchErr
+}
+
+// queueCall adds a call of `method` to the batch and returns a future for its result of type `T`
+func queueCall[T any](b *Batch, method string, params map[string]interface{}) *Future[T] {
+	f := &Future[T]{}
+	f.call = &batchCall{
+		method: method,
+		params: params,
+		decode: func(raw json.RawMessage) error {
+			return json.Unmarshal(raw, &f.result)
+		},
+	}
+
+	b.calls = append(b.calls, f.call)
+
+	return f
+}
+
+// renderScript renders VKScript code calling all `calls` and returning their results as an array
+func renderScript(calls []*batchCall) string {
+	var buf bytes.Buffer
+
+	buf.WriteString("return [")
+
+	for k, c := range calls {
+		if k > 0 {
+			buf.WriteString(",")
+		}
+
+		buf.WriteString("API.")
+		buf.WriteString(c.method)
+		buf.WriteString("({")
+
+		// sort keys to get the same script for the same calls
+		keys := make([]string, 0, len(c.params))
+
+		for pk := range c.params {
+			keys = append(keys, pk)
+		}
+
+		sort.Strings(keys)
+
+		for pk, name := range keys {
+			if pk > 0 {
+				buf.WriteString(",")
+			}
+
+			buf.WriteString(quoteScript(name))
+			buf.WriteString(":")
+			buf.WriteString(scriptValue(c.params[name]))
+		}
+
+		buf.WriteString("})")
+	}
+
+	buf.WriteString("];")
+
+	return buf.String()
+}
+
+// scriptValue renders parameter value as a VKScript string literal. Values are encoded with `EncodeParam`
+// like for direct requests (generated methods pass them already encoded), so numbers and booleans are quoted
+// too, e.g. `"count":"10"`: API methods called from `execute` accept the same string values as over HTTP.
+func scriptValue(v interface{}) string {
+	s, err := EncodeParam(v)
+
+	if err != nil {
+		s = fmt.Sprint(v)
+	}
+
+	return quoteScript(s)
+}
+
+// quoteScript renders `s` as a double quoted VKScript string literal escaping special characters
+func quoteScript(s string) string {
+	var buf bytes.Buffer
+
+	buf.WriteByte('"')
+
+	for _, r := range s {
+		switch r {
+		case '"':
+			buf.WriteString(`\"`)
+		case '\\':
+			buf.WriteString(`\\`)
+		case '\n':
+			buf.WriteString(`\n`)
+		case '\r':
+			buf.WriteString(`\r`)
+		case '\t':
+			buf.WriteString(`\t`)
+		default:
+			if r < 0x20 || r == 0x2028 || r == 0x2029 {
+				fmt.Fprintf(&buf, `\u%04x`, r)
+			} else {
+				buf.WriteRune(r)
+			}
+		}
+	}
+
+	buf.WriteByte('"')
+
+	return buf.String()
+}
@@ -0,0 +1,174 @@
+/*
+Copyright 2019 Konstantin Vasilev (burmuley@gmail.com)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package go_vkapi
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Burmuley/go-vkapi/errors"
+)
+
+func Test_renderScript(t *testing.T) {
+	tests := []struct {
+		name  string
+		calls []*batchCall
+		want  string
+	}{
+		{
+			"TestSingleCall",
+			[]*batchCall{{method: "users.get", params: map[string]interface{}{"user_ids": "1,2", "count": 10}}},
+			`return [API.users.get({"count":"10","user_ids":"1,2"})];`,
+		},
+		{
+			"TestMultipleCalls",
+			[]*batchCall{
+				{method: "account.getProfileInfo", params: map[string]interface{}{}},
+				{method: "account.setOnline", params: map[string]interface{}{"voip": true}},
+			},
+			`return [API.account.getProfileInfo({}),API.account.setOnline({"voip":"1"})];`,
+		},
+		{
+			// generated methods pass parameters encoded, numbers are sent as strings like over HTTP
+			"TestEncodedInt",
+			[]*batchCall{{method: "groups.getById", params: map[string]interface{}{"group_id": EncodeInt(-1), "fields": EncodeStrings([]string{"city"})}}},
+			`return [API.groups.getById({"fields":"city","group_id":"-1"})];`,
+		},
+		{
+			"TestEscaping",
+			[]*batchCall{{method: "wall.post", params: map[string]interface{}{"message": "\"quoted\" \\ line\nbreak\x01"}}},
+			`return [API.wall.post({"message":"\"quoted\" \\ line\nbreak\u0001"})];`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := renderScript(tt.calls); got != tt.want {
+				t.Errorf("renderScript() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBatch_Execute(t *testing.T) {
+	var requests []int
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		code := r.FormValue("code")
+		n := strings.Count(code, "API.")
+		requests = append(requests, n)
+
+		switch {
+		case strings.Contains(code, "API.wall.get"):
+			fmt.Fprint(w, `{"response":[false,1,false,false],"execute_errors":[`+
+				`{"method":"wall.get","error_code":15,"error_msg":"Access denied"},`+
+				`{"method":"users.get","error_code":18,"error_msg":"User was deleted"},`+
+				`{"method":"wall.get","error_code":30,"error_msg":"Profile is private"}]}`)
+		case strings.Contains(code, "API.groups.get"):
+			fmt.Fprint(w, `{"response":[false,1],"execute_errors":[{"method":"users.get","error_code":15,"error_msg":"Access denied"}]}`)
+		default:
+			results := make([]string, n)
+
+			for k := range results {
+				results[k] = fmt.Sprint(len(requests)*100 + k)
+			}
+
+			fmt.Fprintf(w, `{"response":[%s]}`, strings.Join(results, ","))
+		}
+	}))
+	defer srv.Close()
+
+	vk := NewApiWithToken("token")
+	vk.apiUrl = srv.URL + "/"
+
+	t.Run("TestChunks", func(t *testing.T) {
+		requests = nil
+		b := NewBatch(vk)
+		futures := make([]*Future[int], 30)
+
+		for k := range futures {
+			futures[k] = queueCall[int](b, "users.get", map[string]interface{}{"user_ids": k})
+		}
+
+		if err := b.Execute(context.Background()); err != nil {
+			t.Fatalf("Execute() error = %v", err)
+		}
+
+		if fmt.Sprint(requests) != "[25 5]" {
+			t.Errorf("execute requests = %v, want [25 5]", requests)
+		}
+
+		for k, f := range futures {
+			want := 100 + k
+
+			if k >= MaxBatchSize {
+				want = 200 + k - MaxBatchSize
+			}
+
+			if got, err := f.Get(); err != nil || got != want {
+				t.Errorf("future %d Get() = %v, %v, want %v", k, got, err, want)
+			}
+		}
+
+		if b.Len() != 0 {
+			t.Errorf("Len() = %d, want 0", b.Len())
+		}
+	})
+
+	t.Run("TestExecuteErrors", func(t *testing.T) {
+		b := NewBatch(vk)
+		futures := []*Future[int]{
+			queueCall[int](b, "wall.get", nil),
+			queueCall[int](b, "account.setOnline", nil),
+			queueCall[int](b, "users.get", nil),
+			queueCall[int](b, "wall.get", nil),
+		}
+
+		if err := b.Execute(context.Background()); err != nil {
+			t.Fatalf("Execute() error = %v", err)
+		}
+
+		wantCodes := []int{15, 0, 18, 30}
+
+		for k, f := range futures {
+			_, err := f.Get()
+			e, _ := err.(errors.ExecuteError)
+
+			if e.Code != wantCodes[k] || (wantCodes[k] == 0 && err != nil) {
+				t.Errorf("future %d Get() error = %v, want code %d", k, err, wantCodes[k])
+			}
+		}
+	})
+
+	t.Run("TestUnmatchedErrors", func(t *testing.T) {
+		b := NewBatch(vk)
+		f := queueCall[int](b, "groups.get", nil)
+		queueCall[int](b, "account.setOnline", nil)
+
+		if err := b.Execute(context.Background()); err == nil {
+			t.Error("Execute() error = nil, want unmatched execute errors")
+		}
+
+		if _, err := f.Get(); err == nil {
+			t.Error("Get() error = nil, want error for the failed call")
+		} else if _, ok := err.(errors.ExecuteError); ok {
+			t.Errorf("Get() error = %v, want not matched to an execute error", err)
+		}
+	})
+}
@@ -0,0 +1,120 @@
+/*
+Copyright 2019 Konstantin Vasilev (burmuley@gmail.com)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package callback implements http.Handler receiving community events via Callback API (https://vk.com/dev/callback_api)
+package callback
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+
+	"github.com/Burmuley/go-vkapi/events"
+)
+
+const (
+	typeConfirmation = "confirmation"
+	maxBodySize      = 1 << 20 // 1 MB
+)
+
+// Group describes Callback API server settings of a community
+type Group struct {
+	Confirmation string // string to return in response to `confirmation` request
+	Secret       string // secret key, `secret` field of requests is not checked if empty
+}
+
+// Handler receives Callback API requests and passes events to `Dispatcher`.
+// Requests from communities not listed in `Groups` or with a wrong secret key are rejected.
+type Handler struct {
+	Groups     map[int]Group                     // communities by ID
+	Dispatcher *events.Dispatcher                // handlers of received events
+	Async      bool                              // respond `ok` immediately and dispatch events in background
+	OnError    func(u *events.Update, err error) // called if an event can't be decoded, errors are dropped if nil
+
+	wg sync.WaitGroup
+}
+
+// NewHandler creates Handler with a new Dispatcher accepting requests from `groups`
+func NewHandler(groups map[int]Group) *Handler {
+	return &Handler{Groups: groups, Dispatcher: events.NewDispatcher()}
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, maxBodySize))
+
+	if err != nil {
+		http.Error(w, "can't read request", http.StatusBadRequest)
+		return
+	}
+
+	u := &events.Update{}
+
+	if err := json.Unmarshal(body, u); err != nil {
+		http.Error(w, "malformed request", http.StatusBadRequest)
+		return
+	}
+
+	group, ok := h.Groups[u.GroupId]
+
+	if !ok {
+		http.Error(w, "unknown group", http.StatusForbidden)
+		return
+	}
+
+	if group.Secret != "" && subtle.ConstantTimeCompare([]byte(group.Secret), []byte(u.Secret)) != 1 {
+		http.Error(w, "wrong secret", http.StatusForbidden)
+		return
+	}
+
+	if u.Type == typeConfirmation {
+		io.WriteString(w, group.Confirmation)
+		return
+	}
+
+	// VK resends events until it gets `ok`, so it's returned even if an event can't be decoded
+	if h.Async {
+		h.wg.Add(1)
+
+		go func() {
+			defer h.wg.Done()
+			h.dispatch(context.Background(), u)
+		}()
+	} else {
+		h.dispatch(r.Context(), u)
+	}
+
+	io.WriteString(w, "ok")
+}
+
+// Wait blocks until all events dispatched in background are processed
+func (h *Handler) Wait() {
+	h.wg.Wait()
+}
+
+func (h *Handler) dispatch(ctx context.Context, u *events.Update) {
+	if err := h.Dispatcher.Dispatch(ctx, u); err != nil && h.OnError != nil {
+		h.OnError(u, err)
+	}
+}
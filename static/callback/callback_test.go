@@ -0,0 +1,159 @@
+/*
+Copyright 2019 Konstantin Vasilev (burmuley@gmail.com)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package callback
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Burmuley/go-vkapi/events"
+)
+
+func TestHandler_ServeHTTP(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		body       string
+		wantStatus int
+		wantBody   string
+		wantEvents []string
+	}{
+		{
+			"TestConfirmation",
+			http.MethodPost,
+			`{"type":"confirmation","group_id":1,"secret":"s1"}`,
+			http.StatusOK,
+			"code1",
+			nil,
+		},
+		{
+			"TestConfirmationNoSecret",
+			http.MethodPost,
+			`{"type":"confirmation","group_id":2}`,
+			http.StatusOK,
+			"code2",
+			nil,
+		},
+		{
+			"TestMessageNew",
+			http.MethodPost,
+			`{"type":"message_new","object":{"message":{"id":5,"text":"hi"}},"group_id":1,"event_id":"e1","secret":"s1"}`,
+			http.StatusOK,
+			"ok",
+			[]string{"message_new:e1:hi"},
+		},
+		{
+			"TestUnknownEvent",
+			http.MethodPost,
+			`{"type":"some_event","object":{},"group_id":2,"event_id":"e2"}`,
+			http.StatusOK,
+			"ok",
+			[]string{"unknown:e2:some_event"},
+		},
+		{
+			"TestMalformedEvent",
+			http.MethodPost,
+			`{"type":"group_join","object":{"user_id":"x"},"group_id":2,"event_id":"e3"}`,
+			http.StatusOK,
+			"ok",
+			[]string{"error:e3"},
+		},
+		{
+			"TestWrongSecret",
+			http.MethodPost,
+			`{"type":"message_new","object":{},"group_id":1,"secret":"s2"}`,
+			http.StatusForbidden,
+			"wrong secret\n",
+			nil,
+		},
+		{
+			"TestUnknownGroup",
+			http.MethodPost,
+			`{"type":"confirmation","group_id":3}`,
+			http.StatusForbidden,
+			"unknown group\n",
+			nil,
+		},
+		{
+			"TestMalformedRequest",
+			http.MethodPost,
+			`{"type":`,
+			http.StatusBadRequest,
+			"malformed request\n",
+			nil,
+		},
+		{
+			"TestMethodNotAllowed",
+			http.MethodGet,
+			``,
+			http.StatusMethodNotAllowed,
+			"method not allowed\n",
+			nil,
+		},
+	}
+
+	for _, async := range []bool{false, true} {
+		for _, tt := range tests {
+			name := tt.name
+
+			if async {
+				name += "Async"
+			}
+
+			t.Run(name, func(t *testing.T) {
+				var (
+					mu  sync.Mutex
+					got []string
+				)
+
+				record := func(s string) {
+					mu.Lock()
+					got = append(got, s)
+					mu.Unlock()
+				}
+
+				h := NewHandler(map[int]Group{1: {Confirmation: "code1", Secret: "s1"}, 2: {Confirmation: "code2"}})
+				h.Async = async
+				h.OnError = func(u *events.Update, err error) { record("error:" + u.EventId) }
+				h.Dispatcher.OnMessageNew(func(ctx context.Context, event *events.MessageNewEvent) {
+					record("message_new:" + event.EventId + ":" + event.Object.Message.Text)
+				})
+				h.Dispatcher.OnGroupJoin(func(ctx context.Context, event *events.GroupJoinEvent) {
+					record("group_join:" + event.EventId)
+				})
+				h.Dispatcher.OnUnknown(func(ctx context.Context, u *events.Update) {
+					record("unknown:" + u.EventId + ":" + u.Type)
+				})
+
+				w := httptest.NewRecorder()
+				h.ServeHTTP(w, httptest.NewRequest(tt.method, "/callback", strings.NewReader(tt.body)))
+				h.Wait()
+
+				if w.Code != tt.wantStatus || w.Body.String() != tt.wantBody {
+					t.Errorf("ServeHTTP() = %d %q, want %d %q", w.Code, w.Body.String(), tt.wantStatus, tt.wantBody)
+				}
+
+				if strings.Join(got, ",") != strings.Join(tt.wantEvents, ",") {
+					t.Errorf("ServeHTTP() events = %v, want %v", got, tt.wantEvents)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,89 @@
+/*
+Copyright 2019 Konstantin Vasilev (burmuley@gmail.com)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package go_vkapi
+
+import (
+	"context"
+
+	"github.com/Burmuley/go-vkapi/errors"
+)
+
+// WithCaptchaSolver sets `solver` to be asked for a solution when VK API requires captcha.
+// The request is resent with `captcha_sid` and `captcha_key` parameters.
+func WithCaptchaSolver(solver CaptchaSolver) Option {
+	return func(vk *VKApi) {
+		vk.captcha = solver
+	}
+}
+
+// WithValidationHandler sets `handler` to be called when VK API requires user validation.
+// The request is resent after the handler returns.
+func WithValidationHandler(handler ValidationHandler) Option {
+	return func(vk *VKApi) {
+		vk.validation = handler
+	}
+}
+
+// challengeMiddleware resends requests after captcha or validation is handled by configured handlers
+func (vk *VKApi) challengeMiddleware(next Handler) Handler {
+	return func(ctx context.Context, req *Request) (*Response, error) {
+		for {
+			resp, err := next(ctx, req)
+
+			if err == nil || !canRetry(req) {
+				return resp, err
+			}
+
+			if handled, hErr := vk.handleChallenge(ctx, err, req.Params); hErr != nil {
+				return nil, hErr
+			} else if !handled {
+				return nil, err
+			}
+		}
+	}
+}
+
+// handleChallenge checks if `err` is a captcha or validation request and handles it using configured handlers.
+// Returns `true` if the request should be resent with updated `parameters`.
+func (vk *VKApi) handleChallenge(ctx context.Context, err error, parameters map[string]interface{}) (bool, error) {
+	apiErr, ok := err.(errors.ApiError)
+
+	if !ok {
+		return false, nil
+	}
+
+	switch {
+	case apiErr.Code == errors.ErrCodeCaptchaNeeded && vk.captcha != nil:
+		key, err := vk.captcha.SolveCaptcha(ctx, apiErr.CaptchaSid, apiErr.CaptchaImg)
+
+		if err != nil {
+			return false, err
+		}
+
+		parameters["captcha_sid"] = apiErr.CaptchaSid
+		parameters["captcha_key"] = key
+
+		return true, nil
+	case apiErr.Code == errors.ErrCodeValidationRequired && vk.validation != nil:
+		if err := vk.validation.HandleValidation(ctx, apiErr.RedirectUri); err != nil {
+			return false, err
+		}
+
+		return true, nil
+	}
+
+	return false, nil
+}
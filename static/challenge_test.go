@@ -0,0 +1,86 @@
+/*
+Copyright 2019 Konstantin Vasilev (burmuley@gmail.com)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package go_vkapi
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type testCaptchaSolver struct {
+	sid, img string
+}
+
+func (s *testCaptchaSolver) SolveCaptcha(ctx context.Context, sid, img string) (string, error) {
+	s.sid, s.img = sid, img
+	return "solved", nil
+}
+
+type testValidationHandler struct {
+	redirectUri string
+}
+
+func (h *testValidationHandler) HandleValidation(ctx context.Context, redirectUri string) error {
+	h.redirectUri = redirectUri
+	return nil
+}
+
+func Test_handleChallenge(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/captcha.test" && r.FormValue("captcha_key") == "":
+			fmt.Fprint(w, `{"error":{"error_code":14,"error_msg":"Captcha needed","captcha_sid":"123","captcha_img":"https://vk.com/captcha.php?sid=123"}}`)
+		case r.URL.Path == "/captcha.test" && r.FormValue("captcha_sid") == "123" && r.FormValue("captcha_key") == "solved":
+			fmt.Fprint(w, `{"response":1}`)
+		case r.URL.Path == "/validation.test":
+			fmt.Fprint(w, `{"error":{"error_code":17,"error_msg":"Validation required","redirect_uri":"https://vk.com/login?act=validation"}}`)
+		default:
+			fmt.Fprint(w, `{"error":{"error_code":1,"error_msg":"Unknown error occurred"}}`)
+		}
+	}))
+	defer srv.Close()
+
+	solver := &testCaptchaSolver{}
+	handler := &testValidationHandler{}
+	vk := NewApiWithToken("token", WithCaptchaSolver(solver), WithValidationHandler(handler))
+	vk.apiUrl = srv.URL + "/"
+
+	t.Run("TestCaptcha", func(t *testing.T) {
+		resp, err := vk.SendAPIRequest("captcha.test", map[string]interface{}{})
+
+		if err != nil || string(resp) != "1" {
+			t.Errorf("SendAPIRequest() = %s, %v, want 1, <nil>", resp, err)
+		}
+
+		if solver.sid != "123" || solver.img != "https://vk.com/captcha.php?sid=123" {
+			t.Errorf("SolveCaptcha() called with %s, %s", solver.sid, solver.img)
+		}
+	})
+
+	t.Run("TestValidation", func(t *testing.T) {
+		// the fake server keeps requiring validation, so the request fails after all attempts
+		if _, err := vk.SendAPIRequest("validation.test", map[string]interface{}{}); err == nil {
+			t.Errorf("SendAPIRequest() error = <nil>, want validation error")
+		}
+
+		if handler.redirectUri != "https://vk.com/login?act=validation" {
+			t.Errorf("HandleValidation() called with %s", handler.redirectUri)
+		}
+	})
+}
@@ -0,0 +1,178 @@
+/*
+Copyright 2019 Konstantin Vasilev (burmuley@gmail.com)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package go_vkapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/Burmuley/go-vkapi/errors"
+	"github.com/Burmuley/go-vkapi/internal/jsoncodec"
+)
+
+// countingReader counts bytes read from the underlying reader
+type countingReader struct {
+	r io.Reader
+	n int
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += n
+
+	return n, err
+}
+
+// decodeResponse decodes API response from `body` in a single pass. `response` field is decoded into `target`
+// if it's not nil or kept as raw bytes otherwise. Returns an `errors.ApiError` if the response has `error` field.
+func decodeResponse(body io.Reader, target interface{}) (*Response, error) {
+	if t, ok := target.(jsoncodec.Unmarshaler); ok {
+		return decodeCodecResponse(body, t)
+	}
+
+	cr := &countingReader{r: body}
+	dec := json.NewDecoder(cr)
+	resp := &Response{}
+
+	if err := expectDelim(dec, '{'); err != nil {
+		return nil, err
+	}
+
+	var apiErr errors.ApiError
+
+	for dec.More() {
+		tok, err := dec.Token()
+
+		if err != nil {
+			return nil, err
+		}
+
+		var dst interface{}
+
+		switch tok {
+		case "response":
+			if target != nil {
+				dst, resp.Decoded = target, true
+			} else {
+				dst = &resp.Raw
+			}
+		case "error":
+			dst = &apiErr
+		case "execute_errors":
+			dst = &resp.ExecuteErrors
+		default:
+			dst = &json.RawMessage{}
+		}
+
+		if err := dec.Decode(dst); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := expectDelim(dec, '}'); err != nil {
+		return nil, err
+	}
+
+	if apiErr.GetCode() != 0 {
+		return nil, apiErr
+	}
+
+	resp.Size = cr.n
+
+	return resp, nil
+}
+
+// codecEnvelope is an API response with `response` field decoded by generated codecs of `target`
+type codecEnvelope struct {
+	resp   *Response
+	target jsoncodec.Unmarshaler
+	apiErr errors.ApiError
+}
+
+func (e *codecEnvelope) UnmarshalJSONFrom(l *jsoncodec.Lexer) {
+	if !l.BeginObject() {
+		return
+	}
+
+	for l.More() {
+		switch string(l.Key()) {
+		case "response":
+			e.target.UnmarshalJSONFrom(l)
+			e.resp.Decoded = true
+		case "error":
+			l.Fallback(&e.apiErr)
+		case "execute_errors":
+			l.Fallback(&e.resp.ExecuteErrors)
+		default:
+			l.Skip()
+		}
+	}
+
+	l.EndObject()
+}
+
+// decodeCodecResponse decodes API response from `body` into `target` having generated codecs (see `VK_API_GEN_CODECS`
+// option of the generator) in a single pass without `encoding/json`
+func decodeCodecResponse(body io.Reader, target jsoncodec.Unmarshaler) (*Response, error) {
+	data, err := io.ReadAll(body)
+
+	if err != nil {
+		return nil, err
+	}
+
+	e := &codecEnvelope{resp: &Response{Size: len(data)}, target: target}
+
+	if err := jsoncodec.Unmarshal(data, e); err != nil {
+		return nil, err
+	}
+
+	if e.apiErr.GetCode() != 0 {
+		return nil, e.apiErr
+	}
+
+	return e.resp, nil
+}
+
+// expectDelim reads next token from `dec` checking it's the `delim`
+func expectDelim(dec *json.Decoder, delim json.Delim) error {
+	tok, err := dec.Token()
+
+	if err != nil {
+		return err
+	}
+
+	if tok != delim {
+		return fmt.Errorf("unexpected token `%v` in API response, expected `%v`", tok, delim)
+	}
+
+	return nil
+}
+
+// RawResponse wraps `next` handler so it keeps raw bytes of the response in `Response.Raw` instead of decoding
+// it directly into `Request.Target`. Middlewares inspecting the response bytes should call `next` through it.
+func RawResponse(next Handler) Handler {
+	return func(ctx context.Context, req *Request) (*Response, error) {
+		target := req.Target
+		req.Target = nil
+		resp, err := next(ctx, req)
+		req.Target = target
+
+		return resp, err
+	}
+}
@@ -0,0 +1,254 @@
+/*
+Copyright 2019 Konstantin Vasilev (burmuley@gmail.com)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package go_vkapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Burmuley/go-vkapi/errors"
+	"github.com/Burmuley/go-vkapi/internal/jsoncodec"
+	"github.com/Burmuley/go-vkapi/responses"
+)
+
+type testDecodeMember struct {
+	Id        int    `json:"id"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+	Domain    string `json:"domain"`
+	Online    int    `json:"online"`
+	City      struct {
+		Id    int    `json:"id"`
+		Title string `json:"title"`
+	} `json:"city"`
+}
+
+type testDecodeMembers struct {
+	Count int                `json:"count"`
+	Items []testDecodeMember `json:"items"`
+}
+
+func Test_decodeResponse(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		target   bool
+		wantRaw  string
+		wantObj  []int
+		wantExec int
+		wantErr  interface{}
+	}{
+		{"TestRaw", `{"response":[1,2]}`, false, `[1,2]`, nil, 0, nil},
+		{"TestTarget", `{"response":[1,2]}`, true, ``, []int{1, 2}, 0, nil},
+		{"TestExecuteErrors", `{"response":[1,false],"execute_errors":[{"method":"a.b","error_code":15}]}`, false, `[1,false]`, nil, 1, nil},
+		{"TestUnknownFields", `{"request_params":[{"key":"v","value":"5.101"}],"response":[1],"x":{"a":[1]}}`, true, ``, []int{1}, 0, nil},
+		{"TestError", `{"error":{"error_code":15,"error_msg":"Access denied"}}`, true, ``, nil, 0, errors.ApiError{}},
+		{"TestNotObject", `[1,2]`, false, ``, nil, 0, ""},
+		{"TestTruncated", `{"response":[1,`, false, ``, nil, 0, ""},
+		{"TestTypeMismatch", `{"response":{"a":1}}`, true, ``, nil, 0, &json.UnmarshalTypeError{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var obj []int
+			var target interface{}
+
+			if tt.target {
+				target = &obj
+			}
+
+			resp, err := decodeResponse(strings.NewReader(tt.body), target)
+
+			if tt.wantErr != nil {
+				if err == nil {
+					t.Fatalf("decodeResponse() error = nil, want %T", tt.wantErr)
+				}
+				if _, isString := tt.wantErr.(string); !isString && reflect.TypeOf(err) != reflect.TypeOf(tt.wantErr) {
+					t.Errorf("decodeResponse() error = %T, want %T", err, tt.wantErr)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("decodeResponse() error = %v", err)
+			}
+			if string(resp.Raw) != tt.wantRaw || resp.Decoded != tt.target {
+				t.Errorf("decodeResponse() = %s (decoded %v), want %s", resp.Raw, resp.Decoded, tt.wantRaw)
+			}
+			if !reflect.DeepEqual(obj, tt.wantObj) {
+				t.Errorf("decodeResponse() decoded %v, want %v", obj, tt.wantObj)
+			}
+			if len(resp.ExecuteErrors) != tt.wantExec || resp.Size != len(tt.body) {
+				t.Errorf("decodeResponse() execute errors = %v, size = %d", resp.ExecuteErrors, resp.Size)
+			}
+		})
+	}
+}
+
+// testCodecIds is a list of integers with a hand-written decoder like generated ones
+type testCodecIds []int
+
+func (v *testCodecIds) UnmarshalJSONFrom(l *jsoncodec.Lexer) {
+	if !l.BeginArray() {
+		return
+	}
+
+	for l.More() {
+		var i int
+		l.Int(&i)
+		*v = append(*v, i)
+	}
+
+	l.EndArray()
+}
+
+func Test_decodeCodecResponse(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		want     testCodecIds
+		wantExec int
+		wantErr  bool
+	}{
+		{"TestResponse", `{"response":[1,"2"],"request_params":[]}`, testCodecIds{1, 2}, 0, false},
+		{"TestExecuteErrors", `{"response":[1],"execute_errors":[{"method":"a.b","error_code":15}]}`, testCodecIds{1}, 1, false},
+		{"TestError", `{"error":{"error_code":15,"error_msg":"Access denied"}}`, nil, 0, true},
+		{"TestInvalid", `{"response":[1,{}]}`, nil, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got testCodecIds
+
+			resp, err := decodeResponse(strings.NewReader(tt.body), &got)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("decodeResponse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("decodeResponse() decoded %v, want %v", got, tt.want)
+			}
+			if !resp.Decoded || resp.Size != len(tt.body) || len(resp.ExecuteErrors) != tt.wantExec {
+				t.Errorf("decodeResponse() = %+v", resp)
+			}
+		})
+	}
+}
+
+func TestRawResponse(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"response":{"count":1,"items":[{"id":1}]}}`)
+	}))
+	defer srv.Close()
+
+	var raw json.RawMessage
+
+	inspect := func(next Handler) Handler {
+		return func(ctx context.Context, req *Request) (*Response, error) {
+			resp, err := RawResponse(next)(ctx, req)
+
+			if err == nil {
+				raw = resp.Raw
+			}
+
+			return resp, err
+		}
+	}
+
+	for _, opts := range [][]Option{nil, {WithMiddleware(inspect)}} {
+		raw = nil
+		vk := NewApiWithToken("token", opts...)
+		vk.apiUrl = srv.URL + "/"
+
+		var members testDecodeMembers
+
+		if err := vk.SendObjRequest("groups.getMembers", map[string]interface{}{}, &members); err != nil {
+			t.Fatalf("SendObjRequest() error = %v", err)
+		}
+		if members.Count != 1 || len(members.Items) != 1 || members.Items[0].Id != 1 {
+			t.Errorf("SendObjRequest() = %+v", members)
+		}
+		if opts != nil && string(raw) != `{"count":1,"items":[{"id":1}]}` {
+			t.Errorf("RawResponse() raw = %s", raw)
+		}
+	}
+}
+
+// benchmarkBody returns `groups.getMembers` response with `n` members
+func benchmarkBody(n int) []byte {
+	members := testDecodeMembers{Count: n, Items: make([]testDecodeMember, n)}
+
+	for i := range members.Items {
+		m := &members.Items[i]
+		m.Id, m.FirstName, m.LastName, m.Domain, m.Online = i+1, "Ivan", "Ivanov", fmt.Sprintf("id%d", i+1), i%2
+		m.City.Id, m.City.Title = 1, "Moscow"
+	}
+
+	b, _ := json.Marshal(map[string]interface{}{"response": members})
+
+	return b
+}
+
+// BenchmarkDecode compares the single pass decoding with reading the whole body and decoding it twice
+func BenchmarkDecode(b *testing.B) {
+	body := benchmarkBody(1000)
+
+	b.Run("Buffered", func(b *testing.B) {
+		b.ReportAllocs()
+		b.SetBytes(int64(len(body)))
+
+		for i := 0; i < b.N; i++ {
+			rBody, err := ioutil.ReadAll(bytes.NewReader(body))
+
+			if err != nil {
+				b.Fatal(err)
+			}
+
+			var apiResp responses.ApiRawResponse
+			var members testDecodeMembers
+
+			if err := json.Unmarshal(rBody, &apiResp); err != nil {
+				b.Fatal(err)
+			}
+
+			if err := json.Unmarshal(apiResp.Response, &members); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("Streaming", func(b *testing.B) {
+		b.ReportAllocs()
+		b.SetBytes(int64(len(body)))
+
+		for i := 0; i < b.N; i++ {
+			var members testDecodeMembers
+
+			if _, err := decodeResponse(bytes.NewReader(body), &members); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -7,9 +7,19 @@ type VKErrors interface {
 	GetDescription() string
 }
 
+// VK API error codes requiring special handling
+const (
+	ErrCodeAuthFailed         = 5  // user authorization failed (invalid or expired token)
+	ErrCodeCaptchaNeeded      = 14 // captcha needed, see `CaptchaSid` and `CaptchaImg`
+	ErrCodeValidationRequired = 17 // validation required, see `RedirectUri`
+)
+
 type ApiError struct {
-	Code    int    `json:"error_code"`
-	Message string `json:"error_msg"`
+	Code        int    `json:"error_code"`
+	Message     string `json:"error_msg"`
+	CaptchaSid  string `json:"captcha_sid,omitempty"`  // captcha ID (error 14)
+	CaptchaImg  string `json:"captcha_img,omitempty"`  // captcha image URL (error 14)
+	RedirectUri string `json:"redirect_uri,omitempty"` // URL to pass validation at (error 17)
 }
 
 func (e ApiError) GetCode() int {
@@ -23,3 +33,22 @@ func (e ApiError) GetDescription() string {
 func (e ApiError) Error() string {
 	return fmt.Sprintf("API ERROR! Code: %d, Message: %s", e.Code, e.Message)
 }
+
+// ExecuteError represents an error of a single API call made inside of `execute` method
+type ExecuteError struct {
+	Method  string `json:"method"`
+	Code    int    `json:"error_code"`
+	Message string `json:"error_msg"`
+}
+
+func (e ExecuteError) GetCode() int {
+	return e.Code
+}
+
+func (e ExecuteError) GetDescription() string {
+	return e.Message
+}
+
+func (e ExecuteError) Error() string {
+	return fmt.Sprintf("API ERROR! Method: %s, Code: %d, Message: %s", e.Method, e.Code, e.Message)
+}
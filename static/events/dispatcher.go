@@ -0,0 +1,86 @@
+/*
+Copyright 2019 Konstantin Vasilev (burmuley@gmail.com)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package events contains typed events of Bots Long Poll API and Callback API
+// and a dispatcher passing them to registered handlers
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// Update is an event in the form it is received from Bots Long Poll or Callback API
+type Update struct {
+	Type    string          `json:"type"`
+	Object  json.RawMessage `json:"object"`
+	GroupId int             `json:"group_id"`
+	EventId string          `json:"event_id,omitempty"`
+	Secret  string          `json:"secret,omitempty"` // Callback API only
+}
+
+// Dispatcher decodes updates into typed events and passes them to handlers registered with `On<Event>` methods
+type Dispatcher struct {
+	mu       sync.RWMutex
+	handlers map[string][]func(ctx context.Context, u *Update) error
+	unknown  []func(ctx context.Context, u *Update)
+}
+
+// NewDispatcher creates Dispatcher without handlers
+func NewDispatcher() *Dispatcher {
+	return &Dispatcher{handlers: make(map[string][]func(ctx context.Context, u *Update) error)}
+}
+
+// OnUnknown registers handler `h` for updates without a typed handler registered
+func (d *Dispatcher) OnUnknown(h func(ctx context.Context, u *Update)) {
+	d.mu.Lock()
+	d.unknown = append(d.unknown, h)
+	d.mu.Unlock()
+}
+
+// register adds decoding handler `h` for events of type `eventType`
+func (d *Dispatcher) register(eventType string, h func(ctx context.Context, u *Update) error) {
+	d.mu.Lock()
+	d.handlers[eventType] = append(d.handlers[eventType], h)
+	d.mu.Unlock()
+}
+
+// Dispatch passes update `u` to all handlers registered for its type.
+// Returns an error if the event object can't be decoded.
+func (d *Dispatcher) Dispatch(ctx context.Context, u *Update) error {
+	d.mu.RLock()
+	handlers := d.handlers[u.Type]
+	unknown := d.unknown
+	d.mu.RUnlock()
+
+	if len(handlers) == 0 {
+		for _, h := range unknown {
+			h(ctx, u)
+		}
+
+		return nil
+	}
+
+	for _, h := range handlers {
+		if err := h(ctx, u); err != nil {
+			return fmt.Errorf("error decoding `%s` event: %s", u.Type, err)
+		}
+	}
+
+	return nil
+}
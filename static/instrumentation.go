@@ -0,0 +1,96 @@
+/*
+Copyright 2019 Konstantin Vasilev (burmuley@gmail.com)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package go_vkapi
+
+import (
+	"context"
+	"time"
+
+	"github.com/Burmuley/go-vkapi/errors"
+)
+
+// RequestInfo describes an attempt to send an API request
+type RequestInfo struct {
+	Method    string
+	TokenType string // access token type set with `WithTokenType` or by a client constructor (e.g. `NewGroupClient`), empty if not set
+	Attempt   int    // number of the attempt starting with 1
+}
+
+// RequestResult describes an outcome of an attempt to send an API request
+type RequestResult struct {
+	Duration  time.Duration
+	ErrorCode int   // VK API error code, 0 if the request succeeded or failed not with an API error
+	Err       error // request error if any
+}
+
+// Instrumentation observes API requests, e.g. to collect metrics or traces
+type Instrumentation interface {
+	// RequestStarted is called before an attempt to send a request,
+	// returned context (e.g. with a trace span) is used to send the request
+	RequestStarted(ctx context.Context, info RequestInfo) context.Context
+	// RequestFinished is called after the attempt with the context returned by `RequestStarted`
+	RequestFinished(ctx context.Context, info RequestInfo, result RequestResult)
+}
+
+// WithInstrumentation enables reporting every attempt to send a request to `inst`
+func WithInstrumentation(inst Instrumentation) Option {
+	return WithMiddleware(Instrument(inst))
+}
+
+// WithTokenType sets type of the access token (e.g. `user`, `group` or `service`) reported to instrumentation.
+// Clients restricted to a token type (e.g. `NewGroupClient`) set their type if it's not set with this option.
+func WithTokenType(tokenType string) Option {
+	return func(vk *VKApi) {
+		vk.tokenType = tokenType
+	}
+}
+
+// withTokenType returns a copy of `vk` reporting `tokenType` unless another one is set with `WithTokenType`.
+// The copy shares the token source, middlewares and auto batching with `vk`, auto batched `execute` requests
+// report the token type of `vk`.
+func (vk *VKApi) withTokenType(tokenType string) *VKApi {
+	if len(vk.tokenType) > 0 {
+		return vk
+	}
+
+	c := *vk
+	c.tokenType = tokenType
+
+	return &c
+}
+
+// Instrument returns middleware reporting requests to `inst`, see `WithInstrumentation`
+func Instrument(inst Instrumentation) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, req *Request) (*Response, error) {
+			info := RequestInfo{Method: req.Method, TokenType: req.TokenType, Attempt: req.Attempt}
+			ctx = inst.RequestStarted(ctx, info)
+			start := time.Now()
+			resp, err := next(ctx, req)
+
+			result := RequestResult{Duration: time.Since(start), Err: err}
+
+			if apiErr, ok := err.(errors.ApiError); ok {
+				result.ErrorCode = apiErr.Code
+			}
+
+			inst.RequestFinished(ctx, info, result)
+
+			return resp, err
+		}
+	}
+}
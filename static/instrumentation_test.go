@@ -0,0 +1,146 @@
+/*
+Copyright 2019 Konstantin Vasilev (burmuley@gmail.com)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package go_vkapi
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestWithInstrumentation(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/captcha.test" && r.FormValue("captcha_key") == "":
+			fmt.Fprint(w, `{"error":{"error_code":14,"error_msg":"Captcha needed","captcha_sid":"1","captcha_img":"img"}}`)
+		case r.URL.Path == "/fail.test":
+			fmt.Fprint(w, `{"error":{"error_code":15,"error_msg":"Access denied"}}`)
+		default:
+			fmt.Fprint(w, `{"response":1}`)
+		}
+	}))
+	defer srv.Close()
+
+	var events []string
+
+	metrics := NewMetrics(time.Hour)
+	funcs := MetricsFuncs{
+		CountError: func(method, tokenType string, code int) {
+			events = append(events, fmt.Sprintf("error %s %s %d", method, tokenType, code))
+		},
+		AddInFlight: func(method, tokenType string, delta int) {
+			events = append(events, fmt.Sprintf("in flight %s %+d", method, delta))
+		},
+	}
+	trace := TraceFunc(func(ctx context.Context, info RequestInfo) (context.Context, func(RequestResult)) {
+		events = append(events, fmt.Sprintf("span %s #%d", info.Method, info.Attempt))
+
+		return ctx, func(r RequestResult) {
+			events = append(events, fmt.Sprintf("end %s #%d %d", info.Method, info.Attempt, r.ErrorCode))
+		}
+	})
+
+	vk := NewApiWithToken("token",
+		WithTokenType("user"),
+		WithCaptchaSolver(&testCaptchaSolver{}),
+		WithInstrumentation(metrics),
+		WithInstrumentation(funcs),
+		WithInstrumentation(trace),
+	)
+	vk.apiUrl = srv.URL + "/"
+
+	vk.SendAPIRequest("captcha.test", map[string]interface{}{})
+	vk.SendAPIRequest("fail.test", map[string]interface{}{})
+
+	t.Run("TestAdapters", func(t *testing.T) {
+		want := []string{
+			"in flight captcha.test +1", "span captcha.test #1", "end captcha.test #1 14", "in flight captcha.test -1", "error captcha.test user 14",
+			"in flight captcha.test +1", "span captcha.test #2", "end captcha.test #2 0", "in flight captcha.test -1",
+			"in flight fail.test +1", "span fail.test #1", "end fail.test #1 15", "in flight fail.test -1", "error fail.test user 15",
+		}
+
+		if !reflect.DeepEqual(events, want) {
+			t.Errorf("events = %v, want %v", events, want)
+		}
+	})
+
+	t.Run("TestMetrics", func(t *testing.T) {
+		want := map[MetricsKey]MethodMetrics{
+			{"captcha.test", "user"}: {Requests: 2, Buckets: []int{2, 0}, Errors: map[int]int{14: 1}},
+			{"fail.test", "user"}:    {Requests: 1, Buckets: []int{1, 0}, Errors: map[int]int{15: 1}},
+		}
+		got := metrics.Snapshot()
+
+		for k, v := range got {
+			if v.TotalDuration <= 0 {
+				t.Errorf("%v total duration = %s, want positive", k, v.TotalDuration)
+			}
+
+			v.TotalDuration = 0
+			got[k] = v
+		}
+
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Snapshot() = %v, want %v", got, want)
+		}
+	})
+}
+
+func TestClientTokenType(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"response":1}`)
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name string
+		opts []Option
+		want []string
+	}{
+		{"TestClientTypes", nil, []string{"user", "group", ""}},
+		{"TestExplicitType", []Option{WithTokenType("service")}, []string{"service", "service", "service"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+
+			trace := TraceFunc(func(ctx context.Context, info RequestInfo) (context.Context, func(RequestResult)) {
+				got = append(got, info.TokenType)
+				return ctx, func(RequestResult) {}
+			})
+
+			vk := NewApiWithToken("token", append(tt.opts, WithApiUrl(srv.URL+"/"), WithInstrumentation(trace))...)
+
+			// clients of different types share one VKApi
+			user, group := NewUserClient(vk), NewGroupClient(vk)
+
+			for _, api := range []*VKApi{user.Groups.(*Groups).VKApi, group.Groups.(*Groups).VKApi, vk} {
+				if _, err := api.SendAPIRequest("groups.getById", map[string]interface{}{}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("token types = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -15,7 +15,33 @@ limitations under the License.
 */
 package go_vkapi
 
+import "context"
+
 type VK interface {
 	SendAPIRequest(method string, parameters map[string]string) ([]byte, error)
 	SendObjRequest(method string, params map[string]string, object interface{}) error
 }
+
+// CaptchaSolver is used to solve a captcha when VK API requires it (error 14)
+type CaptchaSolver interface {
+	// SolveCaptcha returns text from the captcha image at `img` URL
+	SolveCaptcha(ctx context.Context, sid, img string) (key string, err error)
+}
+
+// ValidationHandler is used to pass user validation when VK API requires it (error 17)
+type ValidationHandler interface {
+	// HandleValidation returns when validation at `redirectUri` is done and the request can be resent
+	HandleValidation(ctx context.Context, redirectUri string) error
+}
+
+// TokenSource provides access tokens to authorize API requests
+type TokenSource interface {
+	// Token returns a token for the next request. It may block until `ctx` is done (e.g. for rate limiting).
+	Token(ctx context.Context) (string, error)
+}
+
+// TokenInvalidator is implemented by token sources which need to know about tokens rejected by VK API (error 5)
+type TokenInvalidator interface {
+	// Invalidate marks `token` as not valid anymore
+	Invalidate(token string)
+}
@@ -0,0 +1,626 @@
+/*
+Copyright 2019 Konstantin Vasilev (burmuley@gmail.com)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package jsoncodec is a runtime of JSON codecs generated for SDK types. Generated codecs decode
+// VK API quirks: numbers and booleans sent as strings, booleans sent as `0`/`1` and empty objects
+// sent as empty arrays. Values the generator can't handle are passed to `encoding/json`.
+package jsoncodec
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Unmarshaler is implemented by types with generated decoders
+type Unmarshaler interface {
+	UnmarshalJSONFrom(l *Lexer)
+}
+
+// Unmarshal decodes JSON `data` into `v`
+func Unmarshal(data []byte, v Unmarshaler) error {
+	_, err := UnmarshalUnknown(data, v)
+	return err
+}
+
+// UnmarshalUnknown decodes JSON `data` into `v` like `Unmarshal` and returns number of object keys
+// skipped because decoders of `v` don't model them
+func UnmarshalUnknown(data []byte, v Unmarshaler) (int, error) {
+	l := &Lexer{data: data}
+	v.UnmarshalJSONFrom(l)
+	l.skipSpaces()
+
+	if l.err == nil && l.pos < len(l.data) {
+		l.errorf("unexpected data after top-level value")
+	}
+
+	return l.unknown, l.err
+}
+
+// Lexer reads JSON values from a byte slice. The first error stops reading, all later calls are no-op.
+type Lexer struct {
+	data    []byte
+	pos     int
+	first   bool // no elements of the current object or array have been read yet
+	unknown int  // number of values skipped with `SkipUnknown`
+	err     error
+}
+
+// Error returns the first error occurred
+func (l *Lexer) Error() error {
+	return l.err
+}
+
+func (l *Lexer) errorf(format string, args ...interface{}) {
+	if l.err == nil {
+		l.err = fmt.Errorf("jsoncodec: offset %d: %s", l.pos, fmt.Sprintf(format, args...))
+	}
+}
+
+// skipSpaces skips whitespaces and returns the next byte or 0 at the end of data
+func (l *Lexer) skipSpaces() byte {
+	for l.pos < len(l.data) {
+		switch c := l.data[l.pos]; c {
+		case ' ', '\t', '\n', '\r':
+			l.pos++
+		default:
+			return c
+		}
+	}
+
+	return 0
+}
+
+// next returns the next byte of a value
+func (l *Lexer) next() byte {
+	if l.err != nil {
+		return 0
+	}
+
+	c := l.skipSpaces()
+
+	if c == 0 {
+		l.errorf("unexpected end of data")
+	}
+
+	return c
+}
+
+// literal consumes `lit` if data continues with it
+func (l *Lexer) literal(lit string) bool {
+	if len(l.data)-l.pos >= len(lit) && string(l.data[l.pos:l.pos+len(lit)]) == lit {
+		l.pos += len(lit)
+		return true
+	}
+
+	return false
+}
+
+// IsNull consumes `null` if it's the next value
+func (l *Lexer) IsNull() bool {
+	return l.next() == 'n' && l.literal("null")
+}
+
+// BeginObject consumes the beginning of an object. It returns false for `null` and for an empty array
+// VK API sends instead of an empty object, in these cases the value is consumed completely.
+func (l *Lexer) BeginObject() bool {
+	switch l.next() {
+	case '{':
+		l.pos++
+		l.first = true
+		return true
+	case '[':
+		l.pos++
+
+		if l.skipSpaces() == ']' {
+			l.pos++
+			return false
+		}
+	case 'n':
+		if l.literal("null") {
+			return false
+		}
+	case 0:
+		return false
+	}
+
+	l.errorf("expected object")
+
+	return false
+}
+
+// EndObject consumes the end of an object
+func (l *Lexer) EndObject() {
+	l.end('}')
+}
+
+// BeginArray consumes the beginning of an array. It returns false for `null`.
+func (l *Lexer) BeginArray() bool {
+	switch l.next() {
+	case '[':
+		l.pos++
+		l.first = true
+		return true
+	case 'n':
+		if l.literal("null") {
+			return false
+		}
+	case 0:
+		return false
+	}
+
+	l.errorf("expected array")
+
+	return false
+}
+
+// EndArray consumes the end of an array
+func (l *Lexer) EndArray() {
+	l.end(']')
+}
+
+func (l *Lexer) end(delim byte) {
+	if c := l.next(); c != delim {
+		if c != 0 {
+			l.errorf("expected `%c`", delim)
+		}
+
+		return
+	}
+
+	l.pos++
+	l.first = false
+}
+
+// More reports whether the current object or array has more elements consuming a comma between them
+func (l *Lexer) More() bool {
+	c := l.next()
+
+	if c == 0 || c == '}' || c == ']' {
+		return false
+	}
+
+	if !l.first {
+		if c != ',' {
+			l.errorf("expected `,`")
+			return false
+		}
+
+		l.pos++
+	}
+
+	l.first = false
+
+	return true
+}
+
+// Key reads a key of an object element and the following colon. The result is valid until the next call.
+func (l *Lexer) Key() []byte {
+	if l.next() != '"' {
+		l.errorf("expected object key")
+		return nil
+	}
+
+	key := l.readBytes()
+
+	if l.next() != ':' {
+		l.errorf("expected `:`")
+		return nil
+	}
+
+	l.pos++
+
+	return key
+}
+
+// FoldKey returns `key` in lower case to match keys case-insensitively like `encoding/json` does
+// or nil if `key` is in lower case already
+func FoldKey(key []byte) []byte {
+	for _, c := range key {
+		if c >= 'A' && c <= 'Z' || c >= utf8.RuneSelf {
+			return bytes.ToLower(key)
+		}
+	}
+
+	return nil
+}
+
+// Int reads an integer, quoted integers are accepted. `null` leaves `v` unchanged.
+func (l *Lexer) Int(v *int) {
+	b, ok := l.scalar()
+
+	if !ok {
+		return
+	}
+
+	if len(b) == 0 {
+		*v = 0
+		return
+	}
+
+	i, ok := parseInt(b)
+
+	if !ok {
+		l.errorf("invalid integer `%s`", b)
+		return
+	}
+
+	*v = i
+}
+
+// parseInt parses a decimal integer without allocations
+func parseInt(b []byte) (int, bool) {
+	// longer numbers may overflow
+	if len(b) > 18 {
+		i, err := strconv.Atoi(string(b))
+		return i, err == nil
+	}
+
+	digits := b
+
+	if b[0] == '-' {
+		digits = b[1:]
+	}
+
+	if len(digits) == 0 {
+		return 0, false
+	}
+
+	n := 0
+
+	for _, c := range digits {
+		if c < '0' || c > '9' {
+			return 0, false
+		}
+
+		n = n*10 + int(c-'0')
+	}
+
+	if len(digits) < len(b) {
+		n = -n
+	}
+
+	return n, true
+}
+
+// Number reads a number, quoted numbers are accepted. `null` leaves `v` unchanged.
+func (l *Lexer) Number(v *json.Number) {
+	s, ok := l.scalar()
+
+	if !ok {
+		return
+	}
+
+	if len(s) > 0 && !isValidNumber(string(s)) {
+		l.errorf("invalid number `%s`", s)
+		return
+	}
+
+	*v = json.Number(s)
+}
+
+// Bool reads a boolean, numbers `0`/`1` and quoted booleans are accepted. `null` leaves `v` unchanged.
+func (l *Lexer) Bool(v *bool) {
+	switch l.next() {
+	case 't', 'f', 'n':
+		switch {
+		case l.literal("true"):
+			*v = true
+		case l.literal("false"):
+			*v = false
+		case !l.literal("null"):
+			l.errorf("expected boolean")
+		}
+
+		return
+	}
+
+	s, ok := l.scalar()
+
+	if !ok {
+		return
+	}
+
+	switch string(s) {
+	case "1", "true":
+		*v = true
+	case "0", "false", "":
+		*v = false
+	default:
+		l.errorf("invalid boolean `%s`", s)
+	}
+}
+
+// String reads a string, numbers are accepted. `null` leaves `v` unchanged.
+func (l *Lexer) String(v *string) {
+	switch l.next() {
+	case '"':
+		l.readString(v)
+	case 'n':
+		if !l.literal("null") {
+			l.errorf("expected string")
+		}
+	default:
+		if s, ok := l.scalar(); ok {
+			*v = string(s)
+		}
+	}
+}
+
+// scalar reads a number or a string returning its text, returns false for `null`
+func (l *Lexer) scalar() ([]byte, bool) {
+	switch c := l.next(); {
+	case c == '"':
+		b := bytes.TrimSpace(l.readBytes())
+		return b, l.err == nil
+	case c == '-' || (c >= '0' && c <= '9'):
+		start := l.pos
+		l.skipNumber()
+		return l.data[start:l.pos], l.err == nil
+	case c == 'n' && l.literal("null"):
+		return nil, false
+	case c != 0:
+		l.errorf("expected number")
+	}
+
+	return nil, false
+}
+
+func (l *Lexer) skipNumber() {
+	for l.pos < len(l.data) {
+		switch c := l.data[l.pos]; {
+		case c >= '0' && c <= '9', c == '-', c == '+', c == '.', c == 'e', c == 'E':
+			l.pos++
+		default:
+			return
+		}
+	}
+}
+
+// readString reads a string starting at the opening quote
+func (l *Lexer) readString(v *string) {
+	if b := l.readBytes(); l.err == nil {
+		*v = string(b)
+	}
+}
+
+// readBytes reads a string starting at the opening quote, the result refers to data if the string isn't escaped
+func (l *Lexer) readBytes() []byte {
+	l.pos++
+	start := l.pos
+	plain := true
+
+	for ; l.pos < len(l.data); l.pos++ {
+		switch c := l.data[l.pos]; {
+		case c == '"':
+			s := l.data[start:l.pos]
+			l.pos++
+
+			if plain && utf8.Valid(s) {
+				return s
+			}
+
+			return []byte(l.unquote(s))
+		case c == '\\':
+			plain = false
+			l.pos++
+		case c < 0x20:
+			l.errorf("invalid character in string")
+			return nil
+		}
+	}
+
+	l.errorf("unexpected end of string")
+
+	return nil
+}
+
+// unquote decodes escape sequences of `s` replacing invalid UTF-8 and surrogates with U+FFFD
+func (l *Lexer) unquote(s []byte) string {
+	var b strings.Builder
+
+	for i := 0; i < len(s); {
+		if s[i] != '\\' {
+			r, size := utf8.DecodeRune(s[i:])
+			b.WriteRune(r)
+			i += size
+			continue
+		}
+
+		if i+1 >= len(s) {
+			l.errorf("invalid escape sequence")
+			return ""
+		}
+
+		switch c := s[i+1]; c {
+		case '"', '\\', '/':
+			b.WriteByte(c)
+		case 'b':
+			b.WriteByte('\b')
+		case 'f':
+			b.WriteByte('\f')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 't':
+			b.WriteByte('\t')
+		case 'u':
+			r, ok := hexRune(s[i+2:])
+
+			if !ok {
+				l.errorf("invalid escape sequence")
+				return ""
+			}
+
+			i += 6
+
+			if utf16.IsSurrogate(r) {
+				r2 := utf8.RuneError
+
+				if i+1 < len(s) && s[i] == '\\' && s[i+1] == 'u' {
+					if v, ok := hexRune(s[i+2:]); ok {
+						r2 = v
+					}
+				}
+
+				if r = utf16.DecodeRune(r, r2); r != utf8.RuneError {
+					i += 6
+				}
+			}
+
+			b.WriteRune(r)
+			continue
+		default:
+			l.errorf("invalid escape sequence")
+			return ""
+		}
+
+		i += 2
+	}
+
+	return b.String()
+}
+
+// hexRune decodes 4 hex digits at the beginning of `s`
+func hexRune(s []byte) (rune, bool) {
+	if len(s) < 4 {
+		return 0, false
+	}
+
+	v, err := strconv.ParseUint(string(s[:4]), 16, 16)
+
+	return rune(v), err == nil
+}
+
+// Skip skips the next value
+func (l *Lexer) Skip() {
+	switch c := l.next(); c {
+	case '{', '[':
+		depth := 0
+
+		for ; l.pos < len(l.data); l.pos++ {
+			switch l.data[l.pos] {
+			case '{', '[':
+				depth++
+			case '}', ']':
+				depth--
+
+				if depth == 0 {
+					l.pos++
+					l.first = false
+					return
+				}
+			case '"':
+				l.readBytes()
+				l.pos--
+
+				if l.err != nil {
+					return
+				}
+			}
+		}
+
+		l.errorf("unexpected end of data")
+	case '"':
+		l.readBytes()
+	case 't', 'f', 'n':
+		if !l.literal("true") && !l.literal("false") && !l.literal("null") {
+			l.errorf("invalid literal")
+		}
+	case 0:
+	default:
+		l.scalar()
+	}
+}
+
+// SkipUnknown skips the value of an object key the decoder doesn't model, such keys are counted
+func (l *Lexer) SkipUnknown() {
+	l.unknown++
+	l.Skip()
+}
+
+// Raw returns bytes of the next value
+func (l *Lexer) Raw() []byte {
+	l.next()
+	start := l.pos
+	l.Skip()
+
+	if l.err != nil {
+		return nil
+	}
+
+	return l.data[start:l.pos]
+}
+
+// Fallback decodes the next value into `v` with `encoding/json`
+func (l *Lexer) Fallback(v interface{}) {
+	raw := l.Raw()
+
+	if l.err != nil {
+		return
+	}
+
+	if err := json.Unmarshal(raw, v); err != nil {
+		l.errorf("%s", err)
+	}
+}
+
+// isValidNumber reports whether `s` is a valid JSON number
+func isValidNumber(s string) bool {
+	if s != "" && s[0] == '-' {
+		s = s[1:]
+	}
+
+	if s == "" {
+		return false
+	}
+
+	switch {
+	case s[0] == '0':
+		s = s[1:]
+	case s[0] >= '1' && s[0] <= '9':
+		s = strings.TrimLeft(s, "0123456789")
+	default:
+		return false
+	}
+
+	if s != "" && s[0] == '.' {
+		if s = s[1:]; s == "" || s[0] < '0' || s[0] > '9' {
+			return false
+		}
+
+		s = strings.TrimLeft(s, "0123456789")
+	}
+
+	if s != "" && (s[0] == 'e' || s[0] == 'E') {
+		if s = s[1:]; s != "" && (s[0] == '+' || s[0] == '-') {
+			s = s[1:]
+		}
+
+		if s == "" || s[0] < '0' || s[0] > '9' {
+			return false
+		}
+
+		s = strings.TrimLeft(s, "0123456789")
+	}
+
+	return s == ""
+}
@@ -0,0 +1,200 @@
+/*
+Copyright 2019 Konstantin Vasilev (burmuley@gmail.com)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jsoncodec
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// testValue decodes a value with `decode` function
+type testValue struct {
+	decode func(l *Lexer) interface{}
+	got    interface{}
+}
+
+func (v *testValue) UnmarshalJSONFrom(l *Lexer) {
+	v.got = v.decode(l)
+}
+
+func decodeInt(l *Lexer) interface{} {
+	v := -1
+	l.Int(&v)
+	return v
+}
+
+func decodeNumber(l *Lexer) interface{} {
+	var v json.Number
+	l.Number(&v)
+	return v
+}
+
+func decodeBool(l *Lexer) interface{} {
+	var v bool
+	l.Bool(&v)
+	return v
+}
+
+func decodeString(l *Lexer) interface{} {
+	v := "unchanged"
+	l.String(&v)
+	return v
+}
+
+// decodeObject decodes an object of integers skipping other values
+func decodeObject(l *Lexer) interface{} {
+	v := map[string]int{}
+
+	if !l.BeginObject() {
+		return v
+	}
+
+	for l.More() {
+		key := string(l.Key())
+
+		if folded := FoldKey([]byte(key)); folded != nil {
+			key = string(folded)
+		}
+
+		if key == "skip" {
+			l.SkipUnknown()
+			continue
+		}
+
+		var i int
+		l.Int(&i)
+		v[key] = i
+	}
+
+	l.EndObject()
+
+	return v
+}
+
+// decodeArrays decodes an array of arrays of integers
+func decodeArrays(l *Lexer) interface{} {
+	var v [][]int
+
+	if !l.BeginArray() {
+		return v
+	}
+
+	for l.More() {
+		var e []int
+
+		if l.BeginArray() {
+			e = []int{}
+
+			for l.More() {
+				var i int
+				l.Int(&i)
+				e = append(e, i)
+			}
+
+			l.EndArray()
+		}
+
+		v = append(v, e)
+	}
+
+	l.EndArray()
+
+	return v
+}
+
+func decodeFallback(l *Lexer) interface{} {
+	var v interface{}
+	l.Fallback(&v)
+	return v
+}
+
+func TestUnmarshal(t *testing.T) {
+	tests := []struct {
+		name    string
+		decode  func(l *Lexer) interface{}
+		data    string
+		want    interface{}
+		wantErr bool
+	}{
+		{"TestInt", decodeInt, ` 42 `, 42, false},
+		{"TestIntNegative", decodeInt, `-7`, -7, false},
+		{"TestIntQuoted", decodeInt, `"42"`, 42, false},
+		{"TestIntEmptyString", decodeInt, `""`, 0, false},
+		{"TestIntNull", decodeInt, `null`, -1, false},
+		{"TestIntFloat", decodeInt, `1.5`, -1, true},
+		{"TestIntLong", decodeInt, `-1234567890123456789`, -1234567890123456789, false},
+		{"TestIntOverflow", decodeInt, `12345678901234567890`, -1, true},
+		{"TestIntMinus", decodeInt, `"-"`, -1, true},
+		{"TestIntObject", decodeInt, `{}`, -1, true},
+		{"TestNumber", decodeNumber, `1.5e3`, json.Number("1.5e3"), false},
+		{"TestNumberQuoted", decodeNumber, `"-0.25"`, json.Number("-0.25"), false},
+		{"TestNumberInvalid", decodeNumber, `"1.2.3"`, json.Number(""), true},
+		{"TestBoolTrue", decodeBool, `true`, true, false},
+		{"TestBoolInt", decodeBool, `1`, true, false},
+		{"TestBoolZero", decodeBool, `0`, false, false},
+		{"TestBoolQuoted", decodeBool, `"1"`, true, false},
+		{"TestBoolInvalid", decodeBool, `2`, false, true},
+		{"TestString", decodeString, `"a b"`, "a b", false},
+		{"TestStringEscapes", decodeString, `"a\"\\\/\n\té😀"`, "a\"\\/\n\té😀", false},
+		{"TestStringLoneSurrogate", decodeString, `"\ud83d!"`, "�!", false},
+		{"TestStringInvalidUTF8", decodeString, "\"a\xffb\"", "a�b", false},
+		{"TestStringNumber", decodeString, `123`, "123", false},
+		{"TestStringNull", decodeString, `null`, "unchanged", false},
+		{"TestStringUnterminated", decodeString, `"abc`, "unchanged", true},
+		{"TestObject", decodeObject, `{"a": 1, "B": "2", "skip": {"x": [1, "]}"]}}`, map[string]int{"a": 1, "b": 2}, false},
+		{"TestObjectEmptyArray", decodeObject, `[ ]`, map[string]int{}, false},
+		{"TestObjectNonEmptyArray", decodeObject, `[1]`, map[string]int{}, true},
+		{"TestObjectMissingComma", decodeObject, `{"a":1 "b":2}`, map[string]int{"a": 1}, true},
+		{"TestArrays", decodeArrays, `[[1,2],[],null,[3]]`, [][]int{{1, 2}, {}, nil, {3}}, false},
+		{"TestArraysNull", decodeArrays, `null`, [][]int(nil), false},
+		{"TestFallback", decodeFallback, `{"a":[1,true,null]}`, map[string]interface{}{"a": []interface{}{float64(1), true, nil}}, false},
+		{"TestTrailingData", decodeInt, `1 2`, 1, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := &testValue{decode: tt.decode}
+			err := Unmarshal([]byte(tt.data), v)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Unmarshal() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(v.got, tt.want) {
+				t.Errorf("Unmarshal() = %#v, want %#v", v.got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnmarshalUnknown(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want int
+	}{
+		{"TestKnown", `{"a":1}`, 0},
+		{"TestUnknown", `{"skip":[1],"a":1,"SKIP":{}}`, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := UnmarshalUnknown([]byte(tt.data), &testValue{decode: decodeObject})
+
+			if err != nil || got != tt.want {
+				t.Errorf("UnmarshalUnknown() = %d, %v, want %d", got, err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+/*
+Copyright 2019 Konstantin Vasilev (burmuley@gmail.com)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jsoncodec
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// Marshaler is implemented by types with generated encoders
+type Marshaler interface {
+	MarshalJSONTo(w *Writer)
+}
+
+// Marshal encodes `v` to JSON, the result is the same `encoding/json` produces
+func Marshal(v Marshaler) ([]byte, error) {
+	w := &Writer{}
+	v.MarshalJSONTo(w)
+
+	return w.buf, w.err
+}
+
+// MarshalQuirks encodes `v` the way VK API sometimes does: numbers as strings and booleans as `0`/`1`.
+// It's used to test that decoders accept such responses.
+func MarshalQuirks(v Marshaler) ([]byte, error) {
+	w := &Writer{quirks: true}
+	v.MarshalJSONTo(w)
+
+	return w.buf, w.err
+}
+
+// Writer appends JSON values to a buffer. The first error is kept and returned by `Marshal`.
+type Writer struct {
+	buf    []byte
+	quirks bool
+	err    error
+}
+
+// Error returns the first error occurred
+func (w *Writer) Error() error {
+	return w.err
+}
+
+// Raw appends `s` as is
+func (w *Writer) Raw(s string) {
+	w.buf = append(w.buf, s...)
+}
+
+// Null appends `null`
+func (w *Writer) Null() {
+	w.buf = append(w.buf, "null"...)
+}
+
+// Int appends an integer
+func (w *Writer) Int(v int) {
+	if w.quirks {
+		w.buf = append(strconv.AppendInt(append(w.buf, '"'), int64(v), 10), '"')
+		return
+	}
+
+	w.buf = strconv.AppendInt(w.buf, int64(v), 10)
+}
+
+// Number appends a number, an empty number is encoded as `0`
+func (w *Writer) Number(v json.Number) {
+	s := string(v)
+
+	if s == "" {
+		s = "0"
+	}
+
+	if !isValidNumber(s) {
+		if w.err == nil {
+			w.err = fmt.Errorf("jsoncodec: invalid number `%s`", s)
+		}
+
+		return
+	}
+
+	if w.quirks {
+		w.buf = append(append(append(w.buf, '"'), s...), '"')
+		return
+	}
+
+	w.buf = append(w.buf, s...)
+}
+
+// Bool appends a boolean
+func (w *Writer) Bool(v bool) {
+	switch {
+	case w.quirks && v:
+		w.buf = append(w.buf, '1')
+	case w.quirks:
+		w.buf = append(w.buf, '0')
+	default:
+		w.buf = strconv.AppendBool(w.buf, v)
+	}
+}
+
+// String appends a quoted string. Strings needing escaping are encoded with `encoding/json`.
+func (w *Writer) String(s string) {
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; c < 0x20 || c >= 0x80 || c == '"' || c == '\\' || c == '<' || c == '>' || c == '&' {
+			w.Fallback(s)
+			return
+		}
+	}
+
+	w.buf = append(append(append(w.buf, '"'), s...), '"')
+}
+
+// Fallback appends `v` encoded with `encoding/json`
+func (w *Writer) Fallback(v interface{}) {
+	b, err := json.Marshal(v)
+
+	if err != nil {
+		if w.err == nil {
+			w.err = err
+		}
+
+		return
+	}
+
+	w.buf = append(w.buf, b...)
+}
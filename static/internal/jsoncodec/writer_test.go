@@ -0,0 +1,120 @@
+/*
+Copyright 2019 Konstantin Vasilev (burmuley@gmail.com)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jsoncodec
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// testRecord encodes its fields with `Writer` the way generated encoders do
+type testRecord struct {
+	Id     int
+	Name   string
+	Rating json.Number
+	Closed bool
+	Tags   []string
+	Extra  interface{}
+}
+
+func (v testRecord) MarshalJSONTo(w *Writer) {
+	w.Raw(`{"Id":`)
+	w.Int(v.Id)
+	w.Raw(`,"Name":`)
+	w.String(v.Name)
+	w.Raw(`,"Rating":`)
+	w.Number(v.Rating)
+	w.Raw(`,"Closed":`)
+	w.Bool(v.Closed)
+	w.Raw(`,"Tags":`)
+
+	if v.Tags == nil {
+		w.Null()
+	} else {
+		w.Raw("[")
+
+		for i, e := range v.Tags {
+			if i > 0 {
+				w.Raw(",")
+			}
+
+			w.String(e)
+		}
+
+		w.Raw("]")
+	}
+
+	w.Raw(`,"Extra":`)
+	w.Fallback(v.Extra)
+	w.Raw("}")
+}
+
+func TestMarshal(t *testing.T) {
+	tests := []struct {
+		name       string
+		v          testRecord
+		wantQuirks string
+		wantErr    bool
+	}{
+		{
+			"TestZero",
+			testRecord{},
+			`{"Id":"0","Name":"","Rating":"0","Closed":0,"Tags":null,"Extra":null}`,
+			false,
+		},
+		{
+			"TestValues",
+			testRecord{1, "name", "1.5", true, []string{"a", "b"}, map[string]int{"a": 1}},
+			`{"Id":"1","Name":"name","Rating":"1.5","Closed":1,"Tags":["a","b"],"Extra":{"a":1}}`,
+			false,
+		},
+		{
+			"TestEscaping",
+			testRecord{Name: "<a href=\"x\">&\n\u2028é\xff", Tags: []string{}},
+			`{"Id":"0","Name":"\u003ca href=\"x\"\u003e\u0026\n\u2028é�","Rating":"0","Closed":0,"Tags":[],"Extra":null}`,
+			false,
+		},
+		{"TestInvalidNumber", testRecord{Rating: "1.2.3"}, ``, true},
+		{"TestUnsupportedFallback", testRecord{Extra: make(chan int)}, ``, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Marshal(tt.v)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Marshal() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			want, err := json.Marshal(tt.v)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != string(want) {
+				t.Errorf("Marshal() = %s, want %s", got, want)
+			}
+
+			quirks, _ := MarshalQuirks(tt.v)
+
+			if string(quirks) != tt.wantQuirks {
+				t.Errorf("MarshalQuirks() = %s, want %s", quirks, tt.wantQuirks)
+			}
+		})
+	}
+}
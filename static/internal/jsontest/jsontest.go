@@ -0,0 +1,175 @@
+/*
+Copyright 2019 Konstantin Vasilev (burmuley@gmail.com)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package jsontest provides helpers for generated JSON round-trip and codec tests of SDK types
+package jsontest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/Burmuley/go-vkapi/internal/jsoncodec"
+)
+
+// Codec is a type with generated JSON codecs
+type Codec interface {
+	jsoncodec.Unmarshaler
+	jsoncodec.Marshaler
+}
+
+// Equivalent checks that generated codecs of `v` (a pointer to the tested type) give the same results
+// as `encoding/json` gives for `plain` (a pointer to a type with the same underlying type but without methods):
+// `sample` is decoded to equal values and encoded back to the same bytes. It also checks that the value
+// encoded with VK API quirks is decoded to the same value and an empty array is decoded as an empty object.
+func Equivalent(sample []byte, v Codec, plain interface{}) error {
+	if err := json.Unmarshal(sample, plain); err != nil {
+		return fmt.Errorf("encoding/json unmarshal: %w", err)
+	}
+
+	if err := jsoncodec.Unmarshal(sample, v); err != nil {
+		return fmt.Errorf("codec unmarshal: %w", err)
+	}
+
+	typ := reflect.TypeOf(v).Elem()
+	got := reflect.ValueOf(v).Elem().Interface()
+
+	if want := reflect.ValueOf(plain).Elem().Convert(typ).Interface(); !reflect.DeepEqual(got, want) {
+		return fmt.Errorf("codec unmarshal: got %+v, want %+v", got, want)
+	}
+
+	want, err := json.Marshal(plain)
+
+	if err != nil {
+		return fmt.Errorf("encoding/json marshal: %w", err)
+	}
+
+	b, err := jsoncodec.Marshal(v)
+
+	if err != nil {
+		return fmt.Errorf("codec marshal: %w", err)
+	}
+
+	if !bytes.Equal(b, want) {
+		return fmt.Errorf("codec marshal: got %s, want %s", b, want)
+	}
+
+	quirks, err := jsoncodec.MarshalQuirks(v)
+
+	if err != nil {
+		return fmt.Errorf("codec marshal with quirks: %w", err)
+	}
+
+	// values are compared after encoding as empty numbers are encoded as zeros
+	if err := sameDecoded(typ, b, quirks); err != nil {
+		return fmt.Errorf("decode %s: %w", quirks, err)
+	}
+
+	if typ.Kind() == reflect.Struct {
+		if err := sameDecoded(typ, []byte("{}"), []byte("[]")); err != nil {
+			return fmt.Errorf("decode empty array: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// sameDecoded: checks that `want` and `got` are decoded to equal values of `typ` with codecs
+func sameDecoded(typ reflect.Type, want, got []byte) error {
+	w, g := reflect.New(typ), reflect.New(typ)
+
+	if err := jsoncodec.Unmarshal(want, w.Interface().(jsoncodec.Unmarshaler)); err != nil {
+		return err
+	}
+
+	if err := jsoncodec.Unmarshal(got, g.Interface().(jsoncodec.Unmarshaler)); err != nil {
+		return err
+	}
+
+	if !reflect.DeepEqual(g.Elem().Interface(), w.Elem().Interface()) {
+		return fmt.Errorf("got %+v, want %+v", g.Elem().Interface(), w.Elem().Interface())
+	}
+
+	return nil
+}
+
+// RoundTrip unmarshals `sample` into `v` (a pointer to the tested type), marshals `v` back and checks that
+// the result semantically contains every value of `sample`. Fields absent in `sample` are ignored,
+// so an error means that the type lost or changed a value defined by the schema.
+func RoundTrip(sample []byte, v interface{}) error {
+	if err := json.Unmarshal(sample, v); err != nil {
+		return fmt.Errorf("unmarshal sample: %w", err)
+	}
+
+	b, err := json.Marshal(v)
+
+	if err != nil {
+		return fmt.Errorf("marshal: %w", err)
+	}
+
+	var want, got interface{}
+
+	if err := json.Unmarshal(sample, &want); err != nil {
+		return fmt.Errorf("decode sample: %w", err)
+	}
+
+	if err := json.Unmarshal(b, &got); err != nil {
+		return fmt.Errorf("decode result: %w", err)
+	}
+
+	return contains("$", want, got)
+}
+
+// contains: checks that `got` contains `want` reporting the first mismatch found at JSON `path`
+func contains(path string, want, got interface{}) error {
+	switch w := want.(type) {
+	case map[string]interface{}:
+		g, ok := got.(map[string]interface{})
+
+		if !ok {
+			return fmt.Errorf("%s: got %v, want object", path, got)
+		}
+
+		for k, v := range w {
+			if err := contains(path+"."+k, v, g[k]); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	case []interface{}:
+		g, ok := got.([]interface{})
+
+		if !ok || len(g) != len(w) {
+			return fmt.Errorf("%s: got %v, want %v", path, got, want)
+		}
+
+		for i := range w {
+			if err := contains(fmt.Sprintf("%s[%d]", path, i), w[i], g[i]); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	if !reflect.DeepEqual(want, got) {
+		return fmt.Errorf("%s: got %v, want %v", path, got, want)
+	}
+
+	return nil
+}
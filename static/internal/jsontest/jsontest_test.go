@@ -0,0 +1,122 @@
+/*
+Copyright 2019 Konstantin Vasilev (burmuley@gmail.com)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jsontest
+
+import (
+	"testing"
+
+	"github.com/Burmuley/go-vkapi/internal/jsoncodec"
+)
+
+func TestRoundTrip(t *testing.T) {
+	type item struct {
+		Id   int    `json:"id"`
+		Name string `json:"name"`
+	}
+
+	type object struct {
+		Count int      `json:"count"`
+		Items []item   `json:"items"`
+		Flags []string `json:"flags"`
+		Next  *object  `json:"next"`
+	}
+
+	tests := []struct {
+		name    string
+		sample  string
+		v       interface{}
+		wantErr bool
+	}{
+		{"TestObject", `{"count":1,"items":[{"id":1,"name":"string"}],"flags":["a"]}`, new(object), false},
+		{"TestScalar", `"string"`, new(string), false},
+		{"TestUnknownField", `{"count":1,"extra":true}`, new(object), true},
+		{"TestNestedUnknownField", `{"items":[{"id":1,"title":"string"}]}`, new(object), true},
+		{"TestTypeMismatch", `{"count":"1"}`, new(object), true},
+		{"TestLostPrecision", `{"count":1.5}`, new(object), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := RoundTrip([]byte(tt.sample), tt.v); (err != nil) != tt.wantErr {
+				t.Errorf("RoundTrip() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// codecItem has hand-written codecs like generated ones, `broken` codecs ignore `name` field
+type codecItem struct {
+	Id     int    `json:"id"`
+	Name   string `json:"name"`
+	broken bool
+}
+
+func (v *codecItem) UnmarshalJSONFrom(l *jsoncodec.Lexer) {
+	if !l.BeginObject() {
+		return
+	}
+
+	for l.More() {
+		switch string(l.Key()) {
+		case "id":
+			l.Int(&v.Id)
+		case "name":
+			if v.broken {
+				l.Skip()
+			} else {
+				l.String(&v.Name)
+			}
+		default:
+			l.Skip()
+		}
+	}
+
+	l.EndObject()
+}
+
+func (v codecItem) MarshalJSONTo(w *jsoncodec.Writer) {
+	w.Raw(`{"id":`)
+	w.Int(v.Id)
+	w.Raw(`,"name":`)
+	w.String(v.Name)
+	w.Raw(`}`)
+}
+
+func TestEquivalent(t *testing.T) {
+	type plainItem codecItem
+
+	tests := []struct {
+		name    string
+		sample  string
+		v       *codecItem
+		wantErr bool
+	}{
+		{"TestEquivalent", `{"id":1,"name":"string","extra":[]}`, &codecItem{}, false},
+		{"TestNotEquivalent", `{"id":1,"name":"string"}`, &codecItem{broken: true}, true},
+		{"TestInvalidSample", `{"id":"1"}`, &codecItem{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plain := &plainItem{broken: tt.v.broken}
+
+			if err := Equivalent([]byte(tt.sample), tt.v, plain); (err != nil) != tt.wantErr {
+				t.Errorf("Equivalent() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -13,14 +13,9 @@ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
 See the License for the specific language governing permissions and
 limitations under the License.
 */
-package go_vkapi
 
-import (
-	"fmt"
-	"strings"
-)
+// Package version holds the VK API version shared by the SDK packages
+package version
 
-// SliceToString converts any slice to a string with slice elements comma delimited
-func SliceToString(slice interface{}) string {
-	return strings.Trim(strings.Join(strings.Fields(fmt.Sprint(slice)), ","), "[]")
-}
+// API is the VK API version sent with every request (`v` parameter)
+const API = "5.101"
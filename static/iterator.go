@@ -0,0 +1,136 @@
+/*
+Copyright 2019 Konstantin Vasilev (burmuley@gmail.com)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package go_vkapi
+
+import "context"
+
+// Iterator iterates over items of paginated method results requesting pages on demand.
+// Stop calling `Next` to break early, cancel the context to abort a pending request.
+//
+//	it := VKWall.GetAll(ctx, ...)
+//	for it.Next() {
+//		fmt.Println(it.Item())
+//	}
+//	if err := it.Err(); err != nil { ... }
+type Iterator[T any] struct {
+	ctx   context.Context
+	fetch func(ctx context.Context) (items []T, more bool, err error)
+	page  []T
+	pos   int
+	item  T
+	more  bool
+	total int
+	err   error
+}
+
+// Next advances to the next item. Returns false when there are no more items or an error occurred.
+func (it *Iterator[T]) Next() bool {
+	for it.pos >= len(it.page) {
+		if !it.more || it.err != nil {
+			return false
+		}
+
+		if it.err = it.ctx.Err(); it.err != nil {
+			return false
+		}
+
+		it.page, it.more, it.err = it.fetch(it.ctx)
+		it.pos = 0
+
+		if it.err != nil {
+			return false
+		}
+	}
+
+	it.item = it.page[it.pos]
+	it.pos++
+
+	return true
+}
+
+// Item returns the current item
+func (it *Iterator[T]) Item() T {
+	return it.item
+}
+
+// Err returns the error that stopped iteration, if any
+func (it *Iterator[T]) Err() error {
+	return it.err
+}
+
+// Total returns the total number of items reported by the last page.
+// Returns `0` before the first page is requested or if the method doesn't report it.
+func (it *Iterator[T]) Total() int {
+	return it.total
+}
+
+// offsetIterator creates Iterator requesting pages of `pageSize` items with `fetch` by increasing offset.
+// If `pageSize` is zero, API default page size is used.
+func offsetIterator[T any](ctx context.Context, pageSize int, fetch func(ctx context.Context, offset, count int) (total int, items []T, err error)) *Iterator[T] {
+	it := &Iterator[T]{ctx: ctx, more: true}
+	offset := 0
+
+	it.fetch = func(ctx context.Context) ([]T, bool, error) {
+		total, items, err := fetch(ctx, offset, pageSize)
+
+		if err != nil {
+			return nil, false, err
+		}
+
+		offset += len(items)
+		it.total = total
+
+		return items, len(items) > 0 && offset < total, nil
+	}
+
+	return it
+}
+
+// CursorIterator is Iterator over results paginated with `start_from`/`next_from` cursors.
+// Response of the page the current item belongs to is available with `Page`, e.g. to get `profiles` and `groups`.
+type CursorIterator[T, R any] struct {
+	*Iterator[T]
+	page R
+}
+
+// Page returns response of the page the current item belongs to
+func (it *CursorIterator[T, R]) Page() R {
+	return it.page
+}
+
+// cursorIterator creates CursorIterator requesting pages of `pageSize` items with `fetch`
+// passing `next_from` of the previous page as `startFrom`. Iteration stops when `next_from` is empty.
+func cursorIterator[T, R any](ctx context.Context, pageSize int, fetch func(ctx context.Context, startFrom string, count int) (resp R, items []T, nextFrom string, err error)) *CursorIterator[T, R] {
+	it := &CursorIterator[T, R]{Iterator: &Iterator[T]{ctx: ctx, more: true}}
+	startFrom := ""
+
+	it.fetch = func(ctx context.Context) ([]T, bool, error) {
+		resp, items, nextFrom, err := fetch(ctx, startFrom, pageSize)
+
+		if err != nil {
+			return nil, false, err
+		}
+
+		// the same cursor would return the same page again
+		more := nextFrom != "" && nextFrom != startFrom
+		startFrom = nextFrom
+		it.page = resp
+
+		return items, more, nil
+	}
+
+	return it
+}
@@ -0,0 +1,160 @@
+/*
+Copyright 2019 Konstantin Vasilev (burmuley@gmail.com)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package go_vkapi
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// fakePages returns `fetch` function serving `total` sequential numbers, recording requested offsets
+func fakePages(total int, requests *[]int, failAt int) func(ctx context.Context, offset, count int) (int, []int, error) {
+	return func(ctx context.Context, offset, count int) (int, []int, error) {
+		*requests = append(*requests, offset)
+
+		if offset == failAt {
+			return 0, nil, errors.New("fail")
+		}
+
+		items := make([]int, 0, count)
+
+		for i := offset; i < total && i < offset+count; i++ {
+			items = append(items, i)
+		}
+
+		return total, items, nil
+	}
+}
+
+func Test_offsetIterator(t *testing.T) {
+	tests := []struct {
+		name         string
+		total        int
+		limit        int // stop iteration after `limit` items
+		failAt       int
+		cancel       bool
+		wantItems    int
+		wantRequests []int
+		wantErr      bool
+	}{
+		{"TestAllPages", 7, 100, -1, false, 7, []int{0, 3, 6}, false},
+		{"TestExactPages", 6, 100, -1, false, 6, []int{0, 3}, false},
+		{"TestEmpty", 0, 100, -1, false, 0, []int{0}, false},
+		{"TestEarlyBreak", 7, 4, -1, false, 4, []int{0, 3}, false},
+		{"TestError", 7, 100, 3, false, 3, []int{0, 3}, true},
+		{"TestCancelled", 7, 100, -1, true, 0, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests []int
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			if tt.cancel {
+				cancel()
+			}
+
+			it := offsetIterator(ctx, 3, fakePages(tt.total, &requests, tt.failAt))
+			items := 0
+
+			for items < tt.limit && it.Next() {
+				if it.Item() != items {
+					t.Errorf("Item() = %d, want %d", it.Item(), items)
+				}
+
+				items++
+			}
+
+			if items != tt.wantItems {
+				t.Errorf("iterated over %d items, want %d", items, tt.wantItems)
+			}
+
+			if !reflect.DeepEqual(requests, tt.wantRequests) {
+				t.Errorf("requested offsets = %v, want %v", requests, tt.wantRequests)
+			}
+
+			if (it.Err() != nil) != tt.wantErr {
+				t.Errorf("Err() = %v, wantErr %v", it.Err(), tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_cursorIterator(t *testing.T) {
+	// pages by cursor, the last page has no `next_from`
+	pages := map[string]struct {
+		items    []int
+		nextFrom string
+	}{
+		"":    {[]int{0, 1}, "c1"},
+		"c1":  {[]int{2, 3}, "c2"},
+		"c2":  {[]int{4}, ""},
+		"bad": {[]int{0}, "bad"},
+	}
+
+	tests := []struct {
+		name         string
+		start        string
+		wantItems    []int
+		wantPages    []string // `next_from` of the page of each item
+		wantRequests []string
+	}{
+		{"TestAllPages", "", []int{0, 1, 2, 3, 4}, []string{"c1", "c1", "c2", "c2", ""}, []string{"", "c1", "c2"}},
+		{"TestSameCursor", "bad", []int{0, 0}, []string{"bad", "bad"}, []string{"bad", "bad"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				requests []string
+				items    []int
+				pageRefs []string
+			)
+
+			it := cursorIterator(context.Background(), 2, func(ctx context.Context, startFrom string, count int) (string, []int, string, error) {
+				if startFrom == "" {
+					startFrom = tt.start
+				}
+
+				requests = append(requests, startFrom)
+				page := pages[startFrom]
+
+				return page.nextFrom, page.items, page.nextFrom, nil
+			})
+
+			for it.Next() {
+				items = append(items, it.Item())
+				pageRefs = append(pageRefs, it.Page())
+			}
+
+			if it.Err() != nil {
+				t.Errorf("Err() = %v", it.Err())
+			}
+
+			if !reflect.DeepEqual(items, tt.wantItems) || !reflect.DeepEqual(pageRefs, tt.wantPages) {
+				t.Errorf("items = %v, pages = %q, want %v, %q", items, pageRefs, tt.wantItems, tt.wantPages)
+			}
+
+			if !reflect.DeepEqual(requests, tt.wantRequests) {
+				t.Errorf("requested cursors = %q, want %q", requests, tt.wantRequests)
+			}
+		})
+	}
+}
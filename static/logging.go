@@ -0,0 +1,137 @@
+/*
+Copyright 2019 Konstantin Vasilev (burmuley@gmail.com)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package go_vkapi
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Burmuley/go-vkapi/errors"
+)
+
+// Value logged instead of sensitive parameters
+const redacted = "[REDACTED]"
+
+// Parameters which values are never logged: exact names and parts of names
+var (
+	sensitiveParams     = []string{"code"}
+	sensitiveParamParts = []string{"password", "token", "secret"}
+)
+
+// IsSensitiveParam reports whether values of parameter `name` must not be logged or recorded: `code` and
+// parameters with `password`, `token` or `secret` in the name (e.g. `access_token`, `old_password`)
+func IsSensitiveParam(name string) bool {
+	name = strings.ToLower(name)
+
+	for _, p := range sensitiveParams {
+		if name == p {
+			return true
+		}
+	}
+
+	for _, p := range sensitiveParamParts {
+		if strings.Contains(name, p) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// WithLogger enables structured logging of every request to `logger`: method name, parameters,
+// attempt number, latency, response size and error code. Access tokens and sensitive parameters are redacted.
+func WithLogger(logger *slog.Logger) Option {
+	return WithMiddleware(Logging(logger))
+}
+
+// Logging returns middleware logging requests to `logger`, see `WithLogger`.
+// Successful requests are logged with `Info` level, failed ones with `Error` level.
+func Logging(logger *slog.Logger) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, req *Request) (*Response, error) {
+			start := time.Now()
+			resp, err := next(ctx, req)
+
+			attrs := []slog.Attr{
+				slog.String("method", req.Method),
+				slog.Attr{Key: "params", Value: redactParams(req.Params)},
+				slog.Int("attempt", req.Attempt),
+				slog.Duration("latency", time.Since(start)),
+			}
+
+			if resp != nil {
+				attrs = append(attrs, slog.Int("response_size", resp.Size))
+			}
+
+			if err == nil {
+				logger.LogAttrs(ctx, slog.LevelInfo, "vk api request", attrs...)
+				return resp, err
+			}
+
+			if apiErr, ok := err.(errors.ApiError); ok {
+				attrs = append(attrs, slog.Int("error_code", apiErr.Code))
+			}
+
+			attrs = append(attrs, slog.String("error", err.Error()))
+			logger.LogAttrs(ctx, slog.LevelError, "vk api request", attrs...)
+
+			return resp, err
+		}
+	}
+}
+
+// LogValue implements `slog.LogValuer`, so requests are logged with the access token and sensitive parameters redacted
+func (r *Request) LogValue() slog.Value {
+	token := ""
+
+	if len(r.Token) > 0 {
+		token = redacted
+	}
+
+	return slog.GroupValue(
+		slog.String("method", r.Method),
+		slog.Attr{Key: "params", Value: redactParams(r.Params)},
+		slog.String("token", token),
+	)
+}
+
+// redactParams returns a group of `params` sorted by name with sensitive values redacted
+func redactParams(params map[string]interface{}) slog.Value {
+	keys := make([]string, 0, len(params))
+
+	for k := range params {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+	attrs := make([]slog.Attr, 0, len(keys))
+
+	for _, k := range keys {
+		if IsSensitiveParam(k) {
+			attrs = append(attrs, slog.String(k, redacted))
+			continue
+		}
+
+		attrs = append(attrs, slog.String(k, fmt.Sprint(params[k])))
+	}
+
+	return slog.GroupValue(attrs...)
+}
@@ -0,0 +1,132 @@
+/*
+Copyright 2019 Konstantin Vasilev (burmuley@gmail.com)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package go_vkapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWithLogger(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/fail.test":
+			fmt.Fprint(w, `{"error":{"error_code":15,"error_msg":"Access denied"}}`)
+		default:
+			fmt.Fprint(w, `{"response":[1,2,3]}`)
+		}
+	}))
+	defer srv.Close()
+
+	var buf bytes.Buffer
+
+	vk := NewApiWithToken("secret-token", WithLogger(slog.New(slog.NewJSONHandler(&buf, nil))))
+	vk.apiUrl = srv.URL + "/"
+
+	tests := []struct {
+		name   string
+		method string
+		params map[string]interface{}
+		want   map[string]interface{}
+	}{
+		{
+			"TestSuccess",
+			"users.test",
+			map[string]interface{}{"user_ids": 1, "password": "qwerty", "old_password": "hunter2"},
+			map[string]interface{}{
+				"level": "INFO", "method": "users.test", "attempt": float64(1), "response_size": float64(len(`{"response":[1,2,3]}`)),
+				"params": map[string]interface{}{"old_password": redacted, "user_ids": "1", "password": redacted},
+			},
+		},
+		{
+			"TestError",
+			"fail.test",
+			map[string]interface{}{"code": "auth-code", "client_secret": "s3cr3t", "access_token": "secret-token"},
+			map[string]interface{}{
+				"level": "ERROR", "method": "fail.test", "error_code": float64(15),
+				"params": map[string]interface{}{"code": redacted, "client_secret": redacted, "access_token": redacted},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf.Reset()
+			vk.SendAPIRequest(tt.method, tt.params)
+
+			for _, v := range []string{"secret-token", "qwerty", "hunter2", "auth-code", "s3cr3t"} {
+				if strings.Contains(buf.String(), v) {
+					t.Fatalf("log contains sensitive value %s: %s", v, buf.String())
+				}
+			}
+
+			var got map[string]interface{}
+
+			if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+				t.Fatalf("invalid log record %q: %v", buf.String(), err)
+			}
+
+			if _, ok := got["latency"]; !ok {
+				t.Errorf("log record %v has no latency", got)
+			}
+
+			for k, v := range tt.want {
+				if fmt.Sprint(got[k]) != fmt.Sprint(v) {
+					t.Errorf("log record %s = %v, want %v", k, got[k], v)
+				}
+			}
+		})
+	}
+
+	t.Run("TestLogValue", func(t *testing.T) {
+		buf.Reset()
+		slog.New(slog.NewTextHandler(&buf, nil)).Info("request", "req", &Request{Method: "users.get", Token: "secret-token"})
+
+		if strings.Contains(buf.String(), "secret-token") || !strings.Contains(buf.String(), "req.token="+redacted) {
+			t.Errorf("logged request = %s, want redacted token", buf.String())
+		}
+	})
+}
+
+func TestIsSensitiveParam(t *testing.T) {
+	tests := []struct {
+		name  string
+		param string
+		want  bool
+	}{
+		{"TestAccessToken", "access_token", true},
+		{"TestPasswordSuffix", "new_password", true},
+		{"TestSecretSuffix", "client_secret", true},
+		{"TestUpperCase", "Refresh_Token", true},
+		{"TestCode", "code", true},
+		{"TestCodeSuffix", "country_code", false},
+		{"TestRegular", "user_ids", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsSensitiveParam(tt.param); got != tt.want {
+				t.Errorf("IsSensitiveParam(%q) = %v, want %v", tt.param, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,234 @@
+/*
+Copyright 2019 Konstantin Vasilev (burmuley@gmail.com)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package longpoll
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Burmuley/go-vkapi"
+	"github.com/Burmuley/go-vkapi/events"
+)
+
+// Checkpoint stores `ts` of the last processed Bots Long Poll response,
+// so a restarted bot resumes from where it has stopped
+type Checkpoint interface {
+	// Load returns saved `ts`, empty string if nothing is saved yet
+	Load(ctx context.Context) (string, error)
+	// Save stores `ts` after all events received before it are processed
+	Save(ctx context.Context, ts string) error
+}
+
+// BotsClient polls Bots Long Poll server for community events and passes them to `Dispatcher`
+type BotsClient struct {
+	Groups     *go_vkapi.Groups                  // used to get Long Poll server
+	GroupId    int                               // community ID
+	Wait       int                               // seconds to wait for events, 25 if not set
+	HTTPClient *http.Client                      // client to send poll requests, `http.DefaultClient` if nil
+	Dispatcher *events.Dispatcher                // handlers of received events
+	Checkpoint Checkpoint                        // storage of the last processed `ts`, not used if nil
+	OnError    func(u *events.Update, err error) // called if an event can't be decoded, errors are dropped if nil
+
+	server string
+	key    string
+	ts     string
+}
+
+// NewBotsClient creates Bots Long Poll client for community `groupId` using `vk` to get Long Poll server.
+// `vk` is expected to be configured with a community access token.
+func NewBotsClient(vk *go_vkapi.VKApi, groupId int) *BotsClient {
+	return &BotsClient{
+		Groups:     &go_vkapi.Groups{VKApi: vk},
+		GroupId:    groupId,
+		Dispatcher: events.NewDispatcher(),
+	}
+}
+
+// botsPollResponse represents Bots Long Poll server response
+type botsPollResponse struct {
+	Ts      lpTs            `json:"ts"`
+	Failed  int             `json:"failed"`
+	Updates []events.Update `json:"updates"`
+}
+
+// lpTs is `ts` value, which is passed either as a string or as a number
+type lpTs string
+
+func (t *lpTs) UnmarshalJSON(b []byte) error {
+	*t = lpTs(strings.Trim(string(bytes.TrimSpace(b)), `"`))
+	return nil
+}
+
+// Run polls the server and dispatches events until `ctx` is done or an error occurs.
+// Events of a response are all dispatched before the next request, then `ts` is saved to `Checkpoint`.
+// Events which can't be decoded are reported to `OnError` and skipped, so they don't stop polling.
+// If a saved `ts` is too old, the server skips lost events and polling continues from the current ones.
+func (c *BotsClient) Run(ctx context.Context) error {
+	var saved string
+
+	if c.Checkpoint != nil {
+		ts, err := c.Checkpoint.Load(ctx)
+
+		if err != nil {
+			return err
+		}
+
+		saved = ts
+	}
+
+	// `ts` returned by the server is used only if there's no saved one
+	if err := c.updateServer(ctx, saved == ""); err != nil {
+		return err
+	}
+
+	if saved != "" {
+		c.ts = saved
+	}
+
+	for {
+		resp, err := c.poll(ctx)
+
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+
+			return err
+		}
+
+		switch resp.Failed {
+		case 0:
+			for k := range resp.Updates {
+				c.dispatch(ctx, &resp.Updates[k])
+			}
+
+			if err := c.setTs(ctx, string(resp.Ts)); err != nil {
+				return err
+			}
+		case 1:
+			// events history is outdated or partially lost, continue from the new `ts`
+			if err := c.setTs(ctx, string(resp.Ts)); err != nil {
+				return err
+			}
+		case 2:
+			// key has expired
+			if err := c.updateServer(ctx, false); err != nil {
+				return err
+			}
+		case 3:
+			// information is lost, request new key and `ts`
+			if err := c.updateServer(ctx, true); err != nil {
+				return err
+			}
+		default:
+			return Error{Failed: resp.Failed}
+		}
+	}
+}
+
+func (c *BotsClient) dispatch(ctx context.Context, u *events.Update) {
+	if err := c.Dispatcher.Dispatch(ctx, u); err != nil && c.OnError != nil {
+		c.OnError(u, err)
+	}
+}
+
+// setTs updates `ts` and saves it to `Checkpoint`
+func (c *BotsClient) setTs(ctx context.Context, ts string) error {
+	c.ts = ts
+
+	if c.Checkpoint == nil {
+		return nil
+	}
+
+	return c.Checkpoint.Save(ctx, ts)
+}
+
+// updateServer requests Long Poll server and key, `ts` is updated only if `updateTs` is true
+func (c *BotsClient) updateServer(ctx context.Context, updateTs bool) error {
+	resp, err := c.Groups.GetlongpollserverContext(ctx, c.GroupId)
+
+	if err != nil {
+		return err
+	}
+
+	c.server = resp.Server
+	c.key = resp.Key
+
+	if updateTs {
+		return c.setTs(ctx, resp.Ts)
+	}
+
+	return nil
+}
+
+// poll sends one request to Long Poll server
+func (c *BotsClient) poll(ctx context.Context) (*botsPollResponse, error) {
+	params := url.Values{}
+	params.Set("key", c.key)
+	params.Set("ts", c.ts)
+
+	var resp botsPollResponse
+
+	if err := request(ctx, c.HTTPClient, c.server, params, c.Wait, &resp); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// FileCheckpoint stores `ts` in a file
+type FileCheckpoint struct {
+	Path string
+}
+
+// Load reads `ts` from the file, returns empty string if the file does not exist
+func (f FileCheckpoint) Load(ctx context.Context) (string, error) {
+	data, err := ioutil.ReadFile(f.Path)
+
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+
+	return strings.TrimSpace(string(data)), err
+}
+
+// Save writes `ts` to a temporary file and renames it, so the file is never left partially written
+func (f FileCheckpoint) Save(ctx context.Context, ts string) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(f.Path), filepath.Base(f.Path)+".tmp")
+
+	if err != nil {
+		return err
+	}
+
+	if _, err := tmp.WriteString(ts); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	return os.Rename(tmp.Name(), f.Path)
+}
@@ -0,0 +1,140 @@
+/*
+Copyright 2019 Konstantin Vasilev (burmuley@gmail.com)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package longpoll
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/Burmuley/go-vkapi"
+	"github.com/Burmuley/go-vkapi/events"
+)
+
+// memoryCheckpoint records all saved `ts` values
+type memoryCheckpoint struct {
+	saved []string
+}
+
+func (m *memoryCheckpoint) Load(ctx context.Context) (string, error) {
+	if len(m.saved) == 0 {
+		return "", nil
+	}
+
+	return m.saved[len(m.saved)-1], nil
+}
+
+func (m *memoryCheckpoint) Save(ctx context.Context, ts string) error {
+	m.saved = append(m.saved, ts)
+	return nil
+}
+
+func TestBotsClient_Run(t *testing.T) {
+	srv := newFakeServer(
+		`{"ts":"91","updates":[`+
+			`{"type":"message_new","object":{"message":{"id":5,"peer_id":10,"from_id":10,"text":"hi"}},"group_id":1,"event_id":"e1"},`+
+			`{"type":"some_event","object":{},"group_id":1,"event_id":"e2"},`+
+			`{"type":"group_join","object":{"user_id":"bad"},"group_id":1,"event_id":"e0"}]}`,
+		`{"failed":1,"ts":150}`,
+		`{"failed":2}`,
+		`{"ts":"151","updates":[{"type":"group_join","object":{"user_id":7,"join_type":"join"},"group_id":1,"event_id":"e3"}]}`,
+	)
+	defer srv.Close()
+
+	vk := go_vkapi.NewApiWithToken("token", go_vkapi.WithApiUrl(srv.URL+"/method/"))
+	client := NewBotsClient(vk, 1)
+	checkpoint := &memoryCheckpoint{saved: []string{"90"}}
+	client.Checkpoint = checkpoint
+
+	var failed []string
+
+	client.OnError = func(u *events.Update, err error) {
+		failed = append(failed, u.EventId)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var got []string
+
+	client.Dispatcher.OnMessageNew(func(ctx context.Context, event *events.MessageNewEvent) {
+		got = append(got, event.EventId+":"+event.Object.Message.Text)
+	})
+
+	client.Dispatcher.OnGroupJoin(func(ctx context.Context, event *events.GroupJoinEvent) {
+		got = append(got, event.EventId+":"+event.Object.JoinType)
+		cancel()
+	})
+
+	client.Dispatcher.OnUnknown(func(ctx context.Context, u *events.Update) {
+		got = append(got, u.EventId+":"+u.Type)
+	})
+
+	if err := client.Run(ctx); err != context.Canceled {
+		t.Errorf("Run() error = %v, want %v", err, context.Canceled)
+	}
+
+	if want := []string{"e1:hi", "e2:some_event", "e3:join"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Run() events = %v, want %v", got, want)
+	}
+
+	// an event which can't be decoded doesn't stop polling
+	if want := []string{"e0"}; !reflect.DeepEqual(failed, want) {
+		t.Errorf("Run() failed events = %v, want %v", failed, want)
+	}
+
+	// polling starts from the saved `ts`, not from the one returned by the server
+	wantPolls := []string{"key1:90", "key1:91", "key1:150", "key2:150"}
+
+	if !reflect.DeepEqual(srv.polls, wantPolls) {
+		t.Errorf("poll requests = %v, want %v", srv.polls, wantPolls)
+	}
+
+	// `ts` is saved only after all events of a response are dispatched
+	wantSaved := []string{"90", "91", "150", "151"}
+
+	if !reflect.DeepEqual(checkpoint.saved, wantSaved) {
+		t.Errorf("saved ts = %v, want %v", checkpoint.saved, wantSaved)
+	}
+}
+
+func TestFileCheckpoint(t *testing.T) {
+	dir, err := os.MkdirTemp("", "checkpoint")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	cp := FileCheckpoint{Path: filepath.Join(dir, "ts")}
+	ctx := context.Background()
+
+	if ts, err := cp.Load(ctx); err != nil || ts != "" {
+		t.Errorf("Load() = %q, %v, want empty ts for missing file", ts, err)
+	}
+
+	for _, v := range []string{"10", "11"} {
+		if err := cp.Save(ctx, v); err != nil {
+			t.Fatalf("Save() error = %v", err)
+		}
+
+		if ts, err := cp.Load(ctx); err != nil || ts != v {
+			t.Errorf("Load() = %q, %v, want %q", ts, err, v)
+		}
+	}
+}
@@ -0,0 +1,203 @@
+/*
+Copyright 2019 Konstantin Vasilev (burmuley@gmail.com)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package longpoll
+
+import (
+	"encoding/json"
+)
+
+// Event codes (https://vk.com/dev/using_longpoll_3)
+const (
+	EventFlagsReplace   = 1
+	EventFlagsSet       = 2
+	EventFlagsReset     = 3
+	EventMessageNew     = 4
+	EventMessageEdit    = 5
+	EventReadIncoming   = 6
+	EventReadOutgoing   = 7
+	EventFriendOnline   = 8
+	EventFriendOffline  = 9
+	EventMessagesDelete = 13
+	EventUserTyping     = 61
+	EventUserTypingChat = 62
+	EventUnreadCounter  = 80
+)
+
+// Event is an update received from Long Poll server
+type Event interface {
+	// Code returns event code (the first element of an update)
+	Code() int
+}
+
+// FlagsEvent - message flags are replaced (1), set (2) or reset (3)
+type FlagsEvent struct {
+	EventCode int
+	MessageId int
+	Flags     int
+	PeerId    int
+}
+
+func (e *FlagsEvent) Code() int { return e.EventCode }
+
+// MessageEvent - a new message (4) or a message is edited (5)
+type MessageEvent struct {
+	EventCode   int
+	MessageId   int
+	Flags       int
+	PeerId      int
+	Timestamp   int
+	Text        string
+	Extra       map[string]string // additional fields like `title` or `from` (for chats)
+	Attachments map[string]string // attachments, with `ModeAttachments`
+	RandomId    int               // with `ModeRandomId`
+}
+
+func (e *MessageEvent) Code() int { return e.EventCode }
+
+// ReadEvent - incoming (6) or outgoing (7) messages are read up to `LocalId`
+type ReadEvent struct {
+	EventCode int
+	PeerId    int
+	LocalId   int
+}
+
+func (e *ReadEvent) Code() int { return e.EventCode }
+
+// FriendEvent - a friend goes online (8) or offline (9)
+type FriendEvent struct {
+	EventCode int
+	UserId    int
+	Extra     int // platform for online event, `1` if offline by timeout for offline event
+	Timestamp int
+}
+
+func (e *FriendEvent) Code() int { return e.EventCode }
+
+// MessagesDeleteEvent - all messages in the dialog up to `LocalId` are deleted (13)
+type MessagesDeleteEvent struct {
+	PeerId  int
+	LocalId int
+}
+
+func (e *MessagesDeleteEvent) Code() int { return EventMessagesDelete }
+
+// TypingEvent - user is typing in a dialog (61) or in a chat (62)
+type TypingEvent struct {
+	EventCode int
+	UserId    int
+	ChatId    int // for typing in a chat only
+}
+
+func (e *TypingEvent) Code() int { return e.EventCode }
+
+// UnreadCounterEvent - number of unread dialogs changed (80)
+type UnreadCounterEvent struct {
+	Count int
+}
+
+func (e *UnreadCounterEvent) Code() int { return EventUnreadCounter }
+
+// UnknownEvent - any other event, `Fields` contains all elements of the update
+type UnknownEvent struct {
+	EventCode int
+	Fields    []json.RawMessage
+}
+
+func (e *UnknownEvent) Code() int { return e.EventCode }
+
+// decodeEvent converts an update array to a typed event
+func decodeEvent(u []json.RawMessage) Event {
+	d := updateDecoder(u)
+	code := d.int(0)
+
+	switch code {
+	case EventFlagsReplace, EventFlagsSet, EventFlagsReset:
+		return &FlagsEvent{EventCode: code, MessageId: d.int(1), Flags: d.int(2), PeerId: d.int(3)}
+	case EventMessageNew, EventMessageEdit:
+		return &MessageEvent{
+			EventCode:   code,
+			MessageId:   d.int(1),
+			Flags:       d.int(2),
+			PeerId:      d.int(3),
+			Timestamp:   d.int(4),
+			Text:        d.string(5),
+			Extra:       d.stringMap(6),
+			Attachments: d.stringMap(7),
+			RandomId:    d.int(8),
+		}
+	case EventReadIncoming, EventReadOutgoing:
+		return &ReadEvent{EventCode: code, PeerId: d.int(1), LocalId: d.int(2)}
+	case EventFriendOnline, EventFriendOffline:
+		// user ID is passed negative
+		return &FriendEvent{EventCode: code, UserId: -d.int(1), Extra: d.int(2), Timestamp: d.int(3)}
+	case EventMessagesDelete:
+		return &MessagesDeleteEvent{PeerId: d.int(1), LocalId: d.int(2)}
+	case EventUserTyping:
+		return &TypingEvent{EventCode: code, UserId: d.int(1)}
+	case EventUserTypingChat:
+		return &TypingEvent{EventCode: code, UserId: d.int(1), ChatId: d.int(2)}
+	case EventUnreadCounter:
+		return &UnreadCounterEvent{Count: d.int(1)}
+	}
+
+	return &UnknownEvent{EventCode: code, Fields: u}
+}
+
+// updateDecoder gives typed access to update array elements, missing or malformed elements are zero values
+type updateDecoder []json.RawMessage
+
+func (d updateDecoder) int(i int) (v int) {
+	if i < len(d) {
+		json.Unmarshal(d[i], &v)
+	}
+
+	return
+}
+
+func (d updateDecoder) string(i int) (v string) {
+	if i < len(d) {
+		json.Unmarshal(d[i], &v)
+	}
+
+	return
+}
+
+func (d updateDecoder) stringMap(i int) map[string]string {
+	if i >= len(d) {
+		return nil
+	}
+
+	// values may be numbers, so decode them as raw JSON first
+	var raw map[string]json.RawMessage
+
+	if err := json.Unmarshal(d[i], &raw); err != nil {
+		return nil
+	}
+
+	m := make(map[string]string, len(raw))
+
+	for k, v := range raw {
+		var s string
+
+		if err := json.Unmarshal(v, &s); err != nil {
+			s = string(v)
+		}
+
+		m[k] = s
+	}
+
+	return m
+}
@@ -0,0 +1,202 @@
+/*
+Copyright 2019 Konstantin Vasilev (burmuley@gmail.com)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package longpoll implements User Long Poll API client (https://vk.com/dev/using_longpoll)
+// and Bots Long Poll API client (https://vk.com/dev/bots_longpoll)
+package longpoll
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Burmuley/go-vkapi"
+)
+
+const (
+	lpVersion   = 3  // Long Poll protocol version
+	defaultWait = 25 // seconds to wait for events
+)
+
+// Mode is a set of additional options of Long Poll response (`mode` parameter)
+type Mode int
+
+const (
+	ModeAttachments Mode = 2   // return attachments
+	ModeExtended    Mode = 8   // return extended set of events
+	ModePts         Mode = 32  // return `pts`
+	ModeExtra       Mode = 64  // return platform of a friend going online
+	ModeRandomId    Mode = 128 // return `random_id` of messages
+)
+
+// Handler is called for each event received from Long Poll server
+type Handler func(ctx context.Context, event Event)
+
+// Client polls User Long Poll server for events
+type Client struct {
+	Messages   *go_vkapi.Messages // used to get Long Poll server
+	GroupId    int                // community ID to get events of community messages with user token
+	Wait       int                // seconds to wait for events, 25 if not set
+	Mode       Mode               // additional options of Long Poll response
+	HTTPClient *http.Client       // client to send poll requests, `http.DefaultClient` if nil
+
+	server string
+	key    string
+	ts     int
+}
+
+// NewClient creates Long Poll client using `vk` to get Long Poll server
+func NewClient(vk *go_vkapi.VKApi) *Client {
+	return &Client{
+		Messages: &go_vkapi.Messages{VKApi: vk},
+		Mode:     ModeAttachments | ModeExtended | ModeRandomId,
+	}
+}
+
+// Error is returned when Long Poll server returns unrecoverable error (e.g. unsupported version)
+type Error struct {
+	Failed int
+}
+
+func (e Error) Error() string {
+	return fmt.Sprintf("LONG POLL ERROR! Failed: %d", e.Failed)
+}
+
+// pollResponse represents Long Poll server response
+type pollResponse struct {
+	Ts      int                 `json:"ts"`
+	Failed  int                 `json:"failed"`
+	Updates [][]json.RawMessage `json:"updates"`
+}
+
+// Run polls the server and calls `handler` for each event until `ctx` is done or an error occurs.
+// Long Poll history expiration and key expiration are handled transparently.
+func (c *Client) Run(ctx context.Context, handler Handler) error {
+	if err := c.updateServer(ctx, true); err != nil {
+		return err
+	}
+
+	for {
+		resp, err := c.poll(ctx)
+
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+
+			return err
+		}
+
+		switch resp.Failed {
+		case 0:
+			c.ts = resp.Ts
+
+			for _, u := range resp.Updates {
+				handler(ctx, decodeEvent(u))
+			}
+		case 1:
+			// events history is outdated or partially lost, continue from the new `ts`
+			c.ts = resp.Ts
+		case 2:
+			// key has expired
+			if err := c.updateServer(ctx, false); err != nil {
+				return err
+			}
+		case 3:
+			// user information is lost, request new key and `ts`
+			if err := c.updateServer(ctx, true); err != nil {
+				return err
+			}
+		default:
+			return Error{Failed: resp.Failed}
+		}
+	}
+}
+
+// updateServer requests Long Poll server and key, `ts` is updated only if `updateTs` is true
+func (c *Client) updateServer(ctx context.Context, updateTs bool) error {
+	resp, err := c.Messages.GetlongpollserverContext(ctx, c.Mode&ModePts != 0, c.GroupId, lpVersion)
+
+	if err != nil {
+		return err
+	}
+
+	c.server = resp.Server
+	c.key = resp.Key
+
+	if updateTs {
+		c.ts = resp.Ts
+	}
+
+	return nil
+}
+
+// poll sends one request to Long Poll server
+func (c *Client) poll(ctx context.Context) (*pollResponse, error) {
+	params := url.Values{}
+	params.Set("key", c.key)
+	params.Set("ts", strconv.Itoa(c.ts))
+	params.Set("mode", strconv.Itoa(int(c.Mode)))
+	params.Set("version", strconv.Itoa(lpVersion))
+
+	var resp pollResponse
+
+	if err := request(ctx, c.HTTPClient, c.server, params, c.Wait, &resp); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// request sends `a_check` request with `params` to Long Poll `server` and decodes response into `out`.
+// If `client` is nil, a client with timeout a bit longer than `wait` is used.
+func request(ctx context.Context, client *http.Client, server string, params url.Values, wait int, out interface{}) error {
+	if wait <= 0 {
+		wait = defaultWait
+	}
+
+	params.Set("act", "a_check")
+	params.Set("wait", strconv.Itoa(wait))
+
+	if !strings.Contains(server, "://") {
+		server = "https://" + server
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server+"?"+params.Encode(), nil)
+
+	if err != nil {
+		return err
+	}
+
+	if client == nil {
+		client = &http.Client{Timeout: time.Duration(wait+10) * time.Second}
+	}
+
+	resp, err := client.Do(req)
+
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
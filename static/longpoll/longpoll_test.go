@@ -0,0 +1,132 @@
+/*
+Copyright 2019 Konstantin Vasilev (burmuley@gmail.com)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package longpoll
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/Burmuley/go-vkapi"
+)
+
+// fakeServer serves `messages.getLongPollServer` and `groups.getLongPollServer` API methods and Long Poll requests
+type fakeServer struct {
+	*httptest.Server
+	mu         sync.Mutex
+	serverReqs int      // number of `getLongPollServer` calls
+	polls      []string // `key:ts` of each poll request
+	responses  []string // Long Poll responses, requests hang after all are sent
+}
+
+func newFakeServer(responses ...string) *fakeServer {
+	f := &fakeServer{responses: responses}
+	f.Server = httptest.NewServer(http.HandlerFunc(f.handle))
+
+	return f
+}
+
+func (f *fakeServer) handle(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+
+	switch r.URL.Path {
+	case "/method/messages.getLongPollServer":
+		f.serverReqs++
+		fmt.Fprintf(w, `{"response":{"key":"key%d","server":"%s/lp","ts":%d}}`, f.serverReqs, f.URL, 100*f.serverReqs)
+		f.mu.Unlock()
+	case "/method/groups.getLongPollServer":
+		f.serverReqs++
+		fmt.Fprintf(w, `{"response":{"key":"key%d","server":"%s/lp","ts":"%d"}}`, f.serverReqs, f.URL, 100*f.serverReqs)
+		f.mu.Unlock()
+	case "/lp":
+		f.polls = append(f.polls, r.FormValue("key")+":"+r.FormValue("ts"))
+
+		if len(f.responses) == 0 {
+			f.mu.Unlock()
+			<-r.Context().Done()
+			return
+		}
+
+		resp := f.responses[0]
+		f.responses = f.responses[1:]
+		f.mu.Unlock()
+		fmt.Fprint(w, resp)
+	default:
+		f.mu.Unlock()
+		http.NotFound(w, r)
+	}
+}
+
+func TestClient_Run(t *testing.T) {
+	srv := newFakeServer(
+		`{"ts":101,"updates":[[4,10,1,2000000001,1570000000,"hello",{"title":" ... ","from":"5"},{"attach1_type":"photo","attach1":"1_2"},777],[8,-5,7,1570000001]]}`,
+		`{"failed":1,"ts":150}`,
+		`{"failed":2}`,
+		`{"failed":3}`,
+		`{"ts":301,"updates":[[80,3,0],[999,"x"]]}`,
+	)
+	defer srv.Close()
+
+	vk := go_vkapi.NewApiWithToken("token", go_vkapi.WithApiUrl(srv.URL+"/method/"))
+	client := NewClient(vk)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var events []Event
+
+	err := client.Run(ctx, func(ctx context.Context, event Event) {
+		events = append(events, event)
+
+		if len(events) == 4 {
+			cancel()
+		}
+	})
+
+	if err != context.Canceled {
+		t.Errorf("Run() error = %v, want %v", err, context.Canceled)
+	}
+
+	wantEvents := []Event{
+		&MessageEvent{
+			EventCode:   EventMessageNew,
+			MessageId:   10,
+			Flags:       1,
+			PeerId:      2000000001,
+			Timestamp:   1570000000,
+			Text:        "hello",
+			Extra:       map[string]string{"title": " ... ", "from": "5"},
+			Attachments: map[string]string{"attach1_type": "photo", "attach1": "1_2"},
+			RandomId:    777,
+		},
+		&FriendEvent{EventCode: EventFriendOnline, UserId: 5, Extra: 7, Timestamp: 1570000001},
+		&UnreadCounterEvent{Count: 3},
+		&UnknownEvent{EventCode: 999, Fields: events[3].(*UnknownEvent).Fields},
+	}
+
+	if !reflect.DeepEqual(events, wantEvents) {
+		t.Errorf("Run() events = %+v, want %+v", events, wantEvents)
+	}
+
+	// failed=1 updates ts, failed=2 updates key only, failed=3 updates both key and ts
+	wantPolls := []string{"key1:100", "key1:101", "key1:150", "key2:150", "key3:300"}
+
+	if !reflect.DeepEqual(srv.polls, wantPolls) {
+		t.Errorf("poll requests = %v, want %v", srv.polls, wantPolls)
+	}
+}
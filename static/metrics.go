@@ -0,0 +1,217 @@
+/*
+Copyright 2019 Konstantin Vasilev (burmuley@gmail.com)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package go_vkapi
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultLatencyBuckets are upper bounds of latency histogram buckets used by `NewMetrics` by default
+var DefaultLatencyBuckets = []time.Duration{
+	10 * time.Millisecond,
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+	10 * time.Second,
+}
+
+// MetricsKey identifies metrics of a method called with a token type
+type MetricsKey struct {
+	Method    string
+	TokenType string
+}
+
+// MethodMetrics are metrics of a method
+type MethodMetrics struct {
+	Requests      int           // number of finished attempts
+	InFlight      int           // number of attempts in progress
+	TotalDuration time.Duration // sum of finished attempts durations
+	Buckets       []int         // numbers of attempts finished within `Metrics` buckets bounds, the last one is for slower attempts
+	Errors        map[int]int   // numbers of failed attempts by VK API error code, 0 for other errors
+}
+
+// Metrics is an in-process Instrumentation collecting per-method latency histograms,
+// error counters and in-flight gauges. Use `Snapshot` to read collected metrics.
+type Metrics struct {
+	buckets []time.Duration
+
+	mu      sync.Mutex
+	methods map[MetricsKey]*MethodMetrics
+}
+
+// NewMetrics creates Metrics with latency histogram `buckets` upper bounds, `DefaultLatencyBuckets` if none are given
+func NewMetrics(buckets ...time.Duration) *Metrics {
+	if len(buckets) == 0 {
+		buckets = DefaultLatencyBuckets
+	}
+
+	sorted := append([]time.Duration(nil), buckets...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	return &Metrics{buckets: sorted, methods: make(map[MetricsKey]*MethodMetrics)}
+}
+
+// Buckets returns upper bounds of latency histogram buckets
+func (m *Metrics) Buckets() []time.Duration {
+	return append([]time.Duration(nil), m.buckets...)
+}
+
+// RequestStarted implements Instrumentation
+func (m *Metrics) RequestStarted(ctx context.Context, info RequestInfo) context.Context {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.get(info).InFlight++
+
+	return ctx
+}
+
+// RequestFinished implements Instrumentation
+func (m *Metrics) RequestFinished(ctx context.Context, info RequestInfo, result RequestResult) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	mm := m.get(info)
+	mm.InFlight--
+	mm.Requests++
+	mm.TotalDuration += result.Duration
+	mm.Buckets[sort.Search(len(m.buckets), func(i int) bool { return result.Duration <= m.buckets[i] })]++
+
+	if result.Err != nil {
+		mm.Errors[result.ErrorCode]++
+	}
+}
+
+// Snapshot returns a copy of collected metrics
+func (m *Metrics) Snapshot() map[MetricsKey]MethodMetrics {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	snapshot := make(map[MetricsKey]MethodMetrics, len(m.methods))
+
+	for k, v := range m.methods {
+		mm := *v
+		mm.Buckets = append([]int(nil), v.Buckets...)
+		mm.Errors = make(map[int]int, len(v.Errors))
+
+		for code, n := range v.Errors {
+			mm.Errors[code] = n
+		}
+
+		snapshot[k] = mm
+	}
+
+	return snapshot
+}
+
+// get returns metrics of the request method and token type creating them if needed, must be called with `mu` locked
+func (m *Metrics) get(info RequestInfo) *MethodMetrics {
+	key := MetricsKey{Method: info.Method, TokenType: info.TokenType}
+	mm, ok := m.methods[key]
+
+	if !ok {
+		mm = &MethodMetrics{Buckets: make([]int, len(m.buckets)+1), Errors: make(map[int]int)}
+		m.methods[key] = mm
+	}
+
+	return mm
+}
+
+// MetricsFuncs is an Instrumentation adapter for metrics libraries (e.g. Prometheus) calling functions
+// which are given labels and values. Nil functions are skipped. For example:
+//
+//	go_vkapi.MetricsFuncs{
+//		ObserveDuration: func(method, tokenType string, d time.Duration) {
+//			latency.WithLabelValues(method, tokenType).Observe(d.Seconds())
+//		},
+//		CountError: func(method, tokenType string, code int) {
+//			errs.WithLabelValues(method, tokenType, strconv.Itoa(code)).Inc()
+//		},
+//		AddInFlight: func(method, tokenType string, delta int) {
+//			inFlight.WithLabelValues(method, tokenType).Add(float64(delta))
+//		},
+//	}
+type MetricsFuncs struct {
+	ObserveDuration func(method, tokenType string, d time.Duration)
+	CountError      func(method, tokenType string, code int) // code is 0 for errors other than VK API ones
+	AddInFlight     func(method, tokenType string, delta int)
+}
+
+// RequestStarted implements Instrumentation
+func (f MetricsFuncs) RequestStarted(ctx context.Context, info RequestInfo) context.Context {
+	if f.AddInFlight != nil {
+		f.AddInFlight(info.Method, info.TokenType, 1)
+	}
+
+	return ctx
+}
+
+// RequestFinished implements Instrumentation
+func (f MetricsFuncs) RequestFinished(ctx context.Context, info RequestInfo, result RequestResult) {
+	if f.AddInFlight != nil {
+		f.AddInFlight(info.Method, info.TokenType, -1)
+	}
+
+	if f.ObserveDuration != nil {
+		f.ObserveDuration(info.Method, info.TokenType, result.Duration)
+	}
+
+	if f.CountError != nil && result.Err != nil {
+		f.CountError(info.Method, info.TokenType, result.ErrorCode)
+	}
+}
+
+// TraceFunc is an Instrumentation adapter for tracing libraries (e.g. OpenTelemetry). It's called when an attempt
+// starts and returns the context of a new span and a function ending the span with the attempt result. For example:
+//
+//	go_vkapi.TraceFunc(func(ctx context.Context, info go_vkapi.RequestInfo) (context.Context, func(go_vkapi.RequestResult)) {
+//		ctx, span := tracer.Start(ctx, "vk "+info.Method, trace.WithAttributes(attribute.Int("vk.attempt", info.Attempt)))
+//
+//		return ctx, func(r go_vkapi.RequestResult) {
+//			if r.Err != nil {
+//				span.RecordError(r.Err)
+//			}
+//
+//			span.End()
+//		}
+//	})
+type TraceFunc func(ctx context.Context, info RequestInfo) (context.Context, func(result RequestResult))
+
+// Context key to pass span ending function from RequestStarted to RequestFinished
+type traceEndKey struct{}
+
+// RequestStarted implements Instrumentation
+func (f TraceFunc) RequestStarted(ctx context.Context, info RequestInfo) context.Context {
+	ctx, end := f(ctx, info)
+
+	return context.WithValue(ctx, traceEndKey{}, end)
+}
+
+// RequestFinished implements Instrumentation
+func (f TraceFunc) RequestFinished(ctx context.Context, info RequestInfo, result RequestResult) {
+	if end, ok := ctx.Value(traceEndKey{}).(func(RequestResult)); ok && end != nil {
+		end(result)
+	}
+}
@@ -0,0 +1,84 @@
+/*
+Copyright 2019 Konstantin Vasilev (burmuley@gmail.com)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package go_vkapi
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/Burmuley/go-vkapi/errors"
+)
+
+// Request is an API method call passed through the middleware chain
+type Request struct {
+	Method    string
+	Params    map[string]interface{} // method parameters, `access_token` and `v` are added when the request is sent
+	Token     string                 // access token, set before middlewares are called
+	TokenType string                 // access token type set with `WithTokenType` or by a client constructor
+	Attempt   int                    // number of the attempt to send the request starting with 1, set before middlewares are called
+	Target    interface{}            // optional destination the `response` field is decoded into, see `Response.Decoded`
+}
+
+// Response is an API response passed through the middleware chain
+type Response struct {
+	Raw           json.RawMessage       // `response` field of the response body, empty if `Decoded` is true
+	Decoded       bool                  // true if `response` field has been decoded into `Request.Target` directly
+	Size          int                   // size of the response body in bytes
+	ExecuteErrors []errors.ExecuteError // errors of methods called from `execute`
+}
+
+// Handler sends an API request. It returns an `errors.ApiError` if VK API responds with an error.
+type Handler func(ctx context.Context, req *Request) (*Response, error)
+
+// Middleware wraps `next` handler adding some behavior before or after the request is sent
+type Middleware func(next Handler) Handler
+
+// WithMiddleware adds middlewares to the chain of request handlers. Middlewares added by all options are
+// called in order the options are given, the first one is the outermost. Every attempt to send a request
+// (with another token or after a captcha is solved) passes all middlewares with `Request.Token` set.
+// With auto batching middlewares receive `execute` requests.
+func WithMiddleware(mw ...Middleware) Option {
+	return func(vk *VKApi) {
+		vk.middlewares = append(vk.middlewares, mw...)
+	}
+}
+
+// buildHandler wraps HTTP transport with configured middlewares and built-in token and challenge handling
+func (vk *VKApi) buildHandler() Handler {
+	h := Handler(vk.transport)
+
+	for i := len(vk.middlewares) - 1; i >= 0; i-- {
+		h = vk.middlewares[i](h)
+	}
+
+	return vk.tokenMiddleware(vk.challengeMiddleware(countAttempts(h)))
+}
+
+// countAttempts increments `Request.Attempt` each time the request is resent
+func countAttempts(next Handler) Handler {
+	return func(ctx context.Context, req *Request) (*Response, error) {
+		req.Attempt++
+
+		return next(ctx, req)
+	}
+}
+
+// canRetry checks if the request can be resent. All built-in middlewares resending requests share one budget
+// of `maxRetryAttempts` retries counted by `Request.Attempt`.
+func canRetry(req *Request) bool {
+	return req.Attempt <= maxRetryAttempts
+}
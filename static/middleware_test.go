@@ -0,0 +1,131 @@
+/*
+Copyright 2019 Konstantin Vasilev (burmuley@gmail.com)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package go_vkapi
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/Burmuley/go-vkapi/errors"
+)
+
+func TestWithMiddleware(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/captcha.test" && r.FormValue("captcha_key") == "":
+			fmt.Fprint(w, `{"error":{"error_code":14,"error_msg":"Captcha needed","captcha_sid":"1","captcha_img":"img"}}`)
+		default:
+			fmt.Fprintf(w, `{"response":"%s:%s"}`, r.FormValue("access_token"), r.FormValue("v"))
+		}
+	}))
+	defer srv.Close()
+
+	var log []string
+
+	trace := func(name string) Middleware {
+		return func(next Handler) Handler {
+			return func(ctx context.Context, req *Request) (*Response, error) {
+				log = append(log, fmt.Sprintf("%s>%s:%s", name, req.Method, req.Token))
+				resp, err := next(ctx, req)
+				log = append(log, fmt.Sprintf("%s<%v", name, err != nil))
+
+				return resp, err
+			}
+		}
+	}
+
+	// fault injection short-circuits the chain
+	fault := func(next Handler) Handler {
+		return func(ctx context.Context, req *Request) (*Response, error) {
+			if req.Method == "fault.test" {
+				return nil, errors.ApiError{Code: 10, Message: "Internal server error"}
+			}
+
+			return next(ctx, req)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		method  string
+		want    string
+		wantErr bool
+		wantLog []string
+	}{
+		{"TestOrder", "users.test", `"token:5.101"`, false, []string{"a>users.test:token", "b>users.test:token", "b<false", "a<false"}},
+		{"TestShortCircuit", "fault.test", "", true, []string{"a>fault.test:token", "a<true"}},
+		{
+			"TestRetry",
+			"captcha.test",
+			`"token:5.101"`,
+			false,
+			[]string{"a>captcha.test:token", "b>captcha.test:token", "b<true", "a<true", "a>captcha.test:token", "b>captcha.test:token", "b<false", "a<false"},
+		},
+	}
+
+	vk := NewApiWithToken("token", WithCaptchaSolver(&testCaptchaSolver{}), WithMiddleware(trace("a"), fault), WithMiddleware(trace("b")))
+	vk.apiUrl = srv.URL + "/"
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log = nil
+			resp, err := vk.SendAPIRequest(tt.method, map[string]interface{}{})
+
+			if (err != nil) != tt.wantErr || (!tt.wantErr && string(resp) != tt.want) {
+				t.Errorf("SendAPIRequest() = %s, %v, want %s", resp, err, tt.want)
+			}
+
+			if !reflect.DeepEqual(log, tt.wantLog) {
+				t.Errorf("middlewares calls = %v, want %v", log, tt.wantLog)
+			}
+		})
+	}
+}
+
+func TestRetryBudget(t *testing.T) {
+	var sent []string
+
+	// captcha is requested first, then every token is rejected
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sent = append(sent, r.FormValue("access_token"))
+
+		if r.FormValue("captcha_key") == "" {
+			fmt.Fprint(w, `{"error":{"error_code":14,"error_msg":"Captcha needed","captcha_sid":"1","captcha_img":"img"}}`)
+			return
+		}
+
+		fmt.Fprint(w, `{"error":{"error_code":5,"error_msg":"User authorization failed"}}`)
+	}))
+	defer srv.Close()
+
+	pool := NewTokenPool([]string{"a", "b", "c", "d", "e"}, 0)
+	vk := NewApiWithTokenSource(pool, WithCaptchaSolver(&testCaptchaSolver{}))
+	vk.apiUrl = srv.URL + "/"
+
+	if _, err := vk.SendAPIRequest("users.test", map[string]interface{}{}); err == nil {
+		t.Error("SendAPIRequest() error = nil, want authorization error")
+	}
+
+	// the first attempt and `maxRetryAttempts` retries shared by token and challenge handling
+	if want := []string{"a", "a", "b", "c"}; !reflect.DeepEqual(sent, want) {
+		t.Errorf("sent requests with tokens %v, want %v", sent, want)
+	}
+}
@@ -0,0 +1,163 @@
+/*
+Copyright 2019 Konstantin Vasilev (burmuley@gmail.com)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package go_vkapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// EncodeInt encodes an integer parameter
+func EncodeInt(v int) string {
+	return strconv.Itoa(v)
+}
+
+// EncodeInt64 encodes a 64-bit integer parameter
+func EncodeInt64(v int64) string {
+	return strconv.FormatInt(v, 10)
+}
+
+// EncodeNumber encodes a number parameter
+func EncodeNumber(v json.Number) string {
+	return v.String()
+}
+
+// EncodeBool encodes a boolean parameter as `1` or `0`
+func EncodeBool(v bool) string {
+	if v {
+		return "1"
+	}
+
+	return "0"
+}
+
+// EncodeStrings encodes a list of strings as a comma delimited string
+func EncodeStrings(v []string) string {
+	return strings.Join(v, ",")
+}
+
+// EncodeInts encodes a list of integers as a comma delimited string
+func EncodeInts(v []int) string {
+	items := make([]string, len(v))
+
+	for k, i := range v {
+		items[k] = strconv.Itoa(i)
+	}
+
+	return strings.Join(items, ",")
+}
+
+// EncodeJSON encodes an object parameter as JSON
+func EncodeJSON(v interface{}) (string, error) {
+	b, err := json.Marshal(v)
+
+	if err != nil {
+		return "", err
+	}
+
+	return string(b), nil
+}
+
+// EncodeParam encodes a parameter value of any type: numbers and strings (including named types) as is,
+// booleans as `1` or `0`, lists of scalars as comma delimited strings, objects and other lists as JSON
+func EncodeParam(v interface{}) (string, error) {
+	switch val := v.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return val, nil
+	case int:
+		return EncodeInt(val), nil
+	case int64:
+		return EncodeInt64(val), nil
+	case json.Number:
+		return EncodeNumber(val), nil
+	case bool:
+		return EncodeBool(val), nil
+	case []string:
+		return EncodeStrings(val), nil
+	case []int:
+		return EncodeInts(val), nil
+	case json.RawMessage:
+		return string(val), nil
+	}
+
+	rv := reflect.ValueOf(v)
+
+	if s, ok := encodeScalar(rv); ok {
+		return s, nil
+	}
+
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		items := make([]string, rv.Len())
+
+		for i := range items {
+			s, ok := encodeScalar(rv.Index(i))
+
+			if !ok {
+				return EncodeJSON(v)
+			}
+
+			items[i] = s
+		}
+
+		return strings.Join(items, ","), nil
+	case reflect.Ptr, reflect.Interface:
+		if rv.IsNil() {
+			return "", nil
+		}
+
+		return EncodeParam(rv.Elem().Interface())
+	}
+
+	return EncodeJSON(v)
+}
+
+// encodeScalar encodes `v` if it's a number, a string or a boolean
+func encodeScalar(v reflect.Value) (string, bool) {
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), true
+	case reflect.Bool:
+		return EncodeBool(v.Bool()), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), true
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 64), true
+	}
+
+	return "", false
+}
+
+// SliceToString converts a list of strings or numbers to a string with elements comma delimited.
+//
+// Deprecated: use EncodeStrings, EncodeInts or EncodeParam.
+func SliceToString(slice interface{}) string {
+	s, err := EncodeParam(slice)
+
+	if err != nil {
+		return fmt.Sprint(slice)
+	}
+
+	return s
+}
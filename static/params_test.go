@@ -0,0 +1,73 @@
+/*
+Copyright 2019 Konstantin Vasilev (burmuley@gmail.com)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package go_vkapi
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+type testParamKind int
+
+type testParamObject struct {
+	Id    int    `json:"id"`
+	Title string `json:"title"`
+}
+
+func TestEncodeParam(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   interface{}
+		want    string
+		wantErr bool
+	}{
+		{"TestNil", nil, "", false},
+		{"TestString", "a b", "a b", false},
+		{"TestInt", 10, "10", false},
+		{"TestInt64", int64(-12345678901), "-12345678901", false},
+		{"TestNumber", json.Number("1.5"), "1.5", false},
+		{"TestBoolTrue", true, "1", false},
+		{"TestBoolFalse", false, "0", false},
+		{"TestStrings", []string{"first name", "last name"}, "first name,last name", false},
+		{"TestInts", []int{1, 2, 3}, "1,2,3", false},
+		{"TestNamedInt", testParamKind(2), "2", false},
+		{"TestNamedInts", []testParamKind{1, 2}, "1,2", false},
+		{"TestFloat", 0.25, "0.25", false},
+		{"TestPointer", func() *int { i := 5; return &i }(), "5", false},
+		{"TestObject", testParamObject{1, "a"}, `{"id":1,"title":"a"}`, false},
+		{"TestMap", map[string]interface{}{"a": 1}, `{"a":1}`, false},
+		{"TestObjects", []testParamObject{{Id: 1}}, `[{"id":1,"title":""}]`, false},
+		{"TestInvalid", make(chan int), "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := EncodeParam(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("EncodeParam() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("EncodeParam() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSliceToString(t *testing.T) {
+	if got := SliceToString([]string{"a b", "c"}); got != "a b,c" {
+		t.Errorf("SliceToString() = %v, want %v", got, "a b,c")
+	}
+}
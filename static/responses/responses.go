@@ -26,7 +26,8 @@ type RequestParams struct {
 }
 
 type ApiRawResponse struct {
-	Error         errors.ApiError `json:"error"`
-	RequestParams []RequestParams `json:"request_params"`
-	Response      json.RawMessage `json:"response"`
+	Error         errors.ApiError       `json:"error"`
+	RequestParams []RequestParams       `json:"request_params"`
+	Response      json.RawMessage       `json:"response"`
+	ExecuteErrors []errors.ExecuteError `json:"execute_errors"`
 }
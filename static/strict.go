@@ -0,0 +1,274 @@
+/*
+Copyright 2019 Konstantin Vasilev (burmuley@gmail.com)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package go_vkapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/Burmuley/go-vkapi/internal/jsoncodec"
+)
+
+// UnknownField is a response field not modeled by an SDK type
+type UnknownField struct {
+	Method string // API method name
+	Type   string // Go type missing the field, e.g. `objects.UsersUserFull`
+	Field  string // JSON field name
+}
+
+// UnknownFieldCount is an unknown field with number of responses it was found in
+type UnknownFieldCount struct {
+	UnknownField
+	Count int
+}
+
+// UnknownFields is a registry of response fields VK sends but SDK types don't model, filled in strict mode
+type UnknownFields struct {
+	mu     sync.Mutex
+	fields map[UnknownField]int
+}
+
+// NewUnknownFields creates an empty registry of unknown fields
+func NewUnknownFields() *UnknownFields {
+	return &UnknownFields{fields: make(map[UnknownField]int)}
+}
+
+// WithStrict enables strict decoding mode: keys of every response decoded by `SendObjRequest` which don't
+// match any field of the target type are recorded to `registry`. Responses are decoded as usual, unknown keys
+// are detected while decoding and only responses having them are inspected once more to find all of them.
+func WithStrict(registry *UnknownFields) Option {
+	return func(vk *VKApi) {
+		vk.unknownFields = registry
+	}
+}
+
+// Fields returns recorded unknown fields sorted by method, type and field name
+func (u *UnknownFields) Fields() []UnknownFieldCount {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	fields := make([]UnknownFieldCount, 0, len(u.fields))
+
+	for f, c := range u.fields {
+		fields = append(fields, UnknownFieldCount{UnknownField: f, Count: c})
+	}
+
+	sort.Slice(fields, func(i, j int) bool {
+		a, b := fields[i], fields[j]
+
+		if a.Method != b.Method {
+			return a.Method < b.Method
+		}
+
+		if a.Type != b.Type {
+			return a.Type < b.Type
+		}
+
+		return a.Field < b.Field
+	})
+
+	return fields
+}
+
+// Method returns unknown fields recorded for `method` grouped by Go type
+func (u *UnknownFields) Method(method string) map[string][]string {
+	result := make(map[string][]string)
+
+	for _, f := range u.Fields() {
+		if f.Method == method {
+			result[f.Type] = append(result[f.Type], f.Field)
+		}
+	}
+
+	return result
+}
+
+// Reset forgets all recorded fields
+func (u *UnknownFields) Reset() {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.fields = make(map[UnknownField]int)
+}
+
+// strictTarget decodes a response into `target` detecting unknown fields: generated codecs count keys they skip,
+// `encoding/json` disallows unknown fields. Raw response is kept in `mismatch` if it has fields `target` doesn't model.
+type strictTarget struct {
+	target   interface{}
+	mismatch []byte
+}
+
+func (s *strictTarget) UnmarshalJSON(b []byte) error {
+	if t, ok := s.target.(jsoncodec.Unmarshaler); ok {
+		unknown, err := jsoncodec.UnmarshalUnknown(b, t)
+
+		if unknown > 0 {
+			s.mismatch = append([]byte(nil), b...)
+		}
+
+		return err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+
+	err := dec.Decode(s.target)
+
+	if err == nil || !strings.HasPrefix(err.Error(), "json: unknown field ") {
+		return err
+	}
+
+	s.mismatch = append([]byte(nil), b...)
+
+	// the first unknown field may hide other decoding errors, decode the response as usual to get them
+	return json.Unmarshal(b, s.target)
+}
+
+// inspect records keys of raw `response` of `method` not matching fields of `object` type
+func (u *UnknownFields) inspect(method string, response []byte, object interface{}) {
+	var value interface{}
+
+	if err := json.Unmarshal(response, &value); err != nil {
+		return
+	}
+
+	found := make(map[UnknownField]struct{})
+	collectUnknown(method, reflect.TypeOf(object), "", value, found)
+
+	if len(found) == 0 {
+		return
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	for f := range found {
+		u.fields[f]++
+	}
+}
+
+// collectUnknown walks decoded JSON `value` along Go type `t` adding keys without matching struct fields to `found`.
+// Anonymous structs are reported with the name of the closest named type `typeName`.
+func collectUnknown(method string, t reflect.Type, typeName string, value interface{}, found map[UnknownField]struct{}) {
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t == nil {
+		return
+	}
+
+	if len(t.Name()) > 0 {
+		typeName = t.String()
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		obj, ok := value.(map[string]interface{})
+
+		if !ok {
+			return
+		}
+
+		fields := jsonFields(t)
+
+		for k, v := range obj {
+			ft, ok := fields[k]
+
+			if !ok {
+				ft, ok = fields[strings.ToLower(k)]
+			}
+
+			if !ok {
+				found[UnknownField{Method: method, Type: typeName, Field: k}] = struct{}{}
+				continue
+			}
+
+			collectUnknown(method, ft, typeName, v, found)
+		}
+	case reflect.Slice, reflect.Array:
+		if items, ok := value.([]interface{}); ok {
+			for _, item := range items {
+				collectUnknown(method, t.Elem(), typeName, item, found)
+			}
+		}
+	case reflect.Map:
+		if obj, ok := value.(map[string]interface{}); ok {
+			for _, v := range obj {
+				collectUnknown(method, t.Elem(), typeName, v, found)
+			}
+		}
+	}
+}
+
+// Cache of JSON fields of struct types
+var jsonFieldsCache sync.Map
+
+// jsonFields returns types of struct `t` fields by JSON name (and lower-cased JSON name for case-insensitive
+// matching like `json.Unmarshal` does), including fields promoted from embedded structs
+func jsonFields(t reflect.Type) map[string]reflect.Type {
+	if cached, ok := jsonFieldsCache.Load(t); ok {
+		return cached.(map[string]reflect.Type)
+	}
+
+	fields := make(map[string]reflect.Type)
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		name := strings.Split(tag, ",")[0]
+
+		if name == "-" && tag == "-" {
+			continue
+		}
+
+		ft := f.Type
+
+		for ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+
+		if f.Anonymous && len(name) == 0 && ft.Kind() == reflect.Struct {
+			for k, v := range jsonFields(ft) {
+				if _, ok := fields[k]; !ok {
+					fields[k] = v
+				}
+			}
+
+			continue
+		}
+
+		if len(f.PkgPath) > 0 {
+			continue
+		}
+
+		if len(name) == 0 {
+			name = f.Name
+		}
+
+		fields[name] = f.Type
+		fields[strings.ToLower(name)] = f.Type
+	}
+
+	jsonFieldsCache.Store(t, fields)
+
+	return fields
+}
@@ -0,0 +1,179 @@
+/*
+Copyright 2019 Konstantin Vasilev (burmuley@gmail.com)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package go_vkapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/Burmuley/go-vkapi/internal/jsoncodec"
+)
+
+type testStrictBase struct {
+	Id int `json:"id"`
+}
+
+type testStrictUser struct {
+	testStrictBase
+	Name    string             `json:"name"`
+	Friends []*testStrictUser  `json:"friends"`
+	City    struct{ Id int }   `json:"city"`
+	Extra   map[string]float64 `json:"extra"`
+}
+
+func TestWithStrict(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/users.test":
+			fmt.Fprint(w, `{"response":[{"id":1,"NAME":"a","online":1,"city":{"id":1,"title":"a"},"friends":[{"id":2,"online":0,"sex":1}],"extra":{"a":1}}]}`)
+		default:
+			fmt.Fprint(w, `{"response":{"id":1}}`)
+		}
+	}))
+	defer srv.Close()
+
+	registry := NewUnknownFields()
+	vk := NewApiWithToken("token", WithStrict(registry))
+	vk.apiUrl = srv.URL + "/"
+
+	for i := 0; i < 2; i++ {
+		var users []testStrictUser
+
+		if err := vk.SendObjRequest("users.test", map[string]interface{}{}, &users); err != nil || len(users) != 1 {
+			t.Fatalf("SendObjRequest() = %v, %v", users, err)
+		}
+	}
+
+	var user testStrictUser
+
+	if err := vk.SendObjRequest("known.test", map[string]interface{}{}, &user); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		want   map[string][]string
+	}{
+		{
+			// `title` of the anonymous `city` struct is reported for the closest named type
+			"TestUnknownFields",
+			"users.test",
+			map[string][]string{"go_vkapi.testStrictUser": {"online", "sex", "title"}},
+		},
+		{"TestKnownFields", "known.test", map[string][]string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := registry.Method(tt.method); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Method() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("TestCount", func(t *testing.T) {
+		for _, f := range registry.Fields() {
+			if f.Count != 2 {
+				t.Errorf("%v recorded %d times, want 2", f.UnknownField, f.Count)
+			}
+		}
+	})
+}
+
+func Test_strictTarget(t *testing.T) {
+	tests := []struct {
+		name         string
+		data         string
+		want         testStrictBase
+		wantMismatch bool
+		wantErr      bool
+	}{
+		{"TestKnownFields", `{"id":1}`, testStrictBase{Id: 1}, false, false},
+		{"TestUnknownFields", `{"online":1,"id":2}`, testStrictBase{Id: 2}, true, false},
+		{"TestInvalidAfterUnknown", `{"online":1,"id":"a"}`, testStrictBase{}, true, true},
+		{"TestInvalid", `{"id":"a"}`, testStrictBase{}, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got testStrictBase
+			s := &strictTarget{target: &got}
+
+			if err := json.Unmarshal([]byte(tt.data), s); (err != nil) != tt.wantErr {
+				t.Fatalf("UnmarshalJSON() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if (s.mismatch != nil) != tt.wantMismatch {
+				t.Errorf("mismatch = %s, want mismatch %v", s.mismatch, tt.wantMismatch)
+			}
+
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("UnmarshalJSON() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// testStrictCodec has a generated-like codec decoding only `id`
+type testStrictCodec struct {
+	Id int
+}
+
+func (v *testStrictCodec) UnmarshalJSONFrom(l *jsoncodec.Lexer) {
+	if !l.BeginObject() {
+		return
+	}
+
+	for l.More() {
+		if string(l.Key()) == "id" {
+			l.Int(&v.Id)
+		} else {
+			l.SkipUnknown()
+		}
+	}
+
+	l.EndObject()
+}
+
+func Test_strictTargetCodec(t *testing.T) {
+	tests := []struct {
+		name         string
+		data         string
+		wantMismatch bool
+	}{
+		{"TestKnownFields", `{"id":1}`, false},
+		{"TestUnknownFields", `{"online":1,"id":1}`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got testStrictCodec
+			s := &strictTarget{target: &got}
+
+			if err := json.Unmarshal([]byte(tt.data), s); err != nil || got.Id != 1 {
+				t.Fatalf("UnmarshalJSON() = %+v, %v", got, err)
+			}
+
+			if (s.mismatch != nil) != tt.wantMismatch {
+				t.Errorf("mismatch = %s, want mismatch %v", s.mismatch, tt.wantMismatch)
+			}
+		})
+	}
+}
@@ -0,0 +1,262 @@
+/*
+Copyright 2019 Konstantin Vasilev (burmuley@gmail.com)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package go_vkapi
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	vkerrors "github.com/Burmuley/go-vkapi/errors"
+)
+
+// ErrNoTokens is returned by token sources when there are no valid tokens left
+var ErrNoTokens = errors.New("no valid access tokens")
+
+// tokenMiddleware sets a token from the token source to each request.
+// If the token is rejected and the source is able to drop it, the request is resent with another token.
+func (vk *VKApi) tokenMiddleware(next Handler) Handler {
+	return func(ctx context.Context, req *Request) (*Response, error) {
+		for {
+			token, err := vk.tokens.Token(ctx)
+
+			if err != nil {
+				return nil, err
+			}
+
+			req.Token = token
+			resp, err := next(ctx, req)
+
+			if err == nil || !canRetry(req) || !vk.invalidateToken(token, err) {
+				return resp, err
+			}
+		}
+	}
+}
+
+// invalidateToken passes `token` to the token source if `err` is an authorization error.
+// Returns `true` if the token source has been notified.
+func (vk *VKApi) invalidateToken(token string, err error) bool {
+	apiErr, ok := err.(vkerrors.ApiError)
+
+	if !ok || apiErr.Code != vkerrors.ErrCodeAuthFailed {
+		return false
+	}
+
+	inv, ok := vk.tokens.(TokenInvalidator)
+
+	if ok {
+		inv.Invalidate(token)
+	}
+
+	return ok
+}
+
+//////////////////////////////////////////////////////////////////////
+// Static token
+//////////////////////////////////////////////////////////////////////
+
+// StaticTokenSource returns TokenSource always returning the same `token`
+func StaticTokenSource(token string) TokenSource {
+	return staticTokenSource(token)
+}
+
+type staticTokenSource string
+
+func (s staticTokenSource) Token(ctx context.Context) (string, error) {
+	return string(s), nil
+}
+
+//////////////////////////////////////////////////////////////////////
+// Token stored in a file
+//////////////////////////////////////////////////////////////////////
+
+// FileTokenSource reads a token from a file. The file is read again when it is modified,
+// so the token can be replaced (e.g. on expiry) without restarting the application.
+type FileTokenSource struct {
+	path    string
+	mu      sync.Mutex
+	token   string
+	modTime time.Time
+	invalid string
+}
+
+// NewFileTokenSource creates FileTokenSource reading a token from the file at `path`
+func NewFileTokenSource(path string) *FileTokenSource {
+	return &FileTokenSource{path: path}
+}
+
+// Token returns the token from the file. Returns `ErrNoTokens` if the file still contains an invalidated token.
+func (f *FileTokenSource) Token(ctx context.Context) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	info, err := os.Stat(f.path)
+
+	if err != nil {
+		return "", err
+	}
+
+	if !info.ModTime().Equal(f.modTime) || f.token == "" {
+		data, err := ioutil.ReadFile(f.path)
+
+		if err != nil {
+			return "", err
+		}
+
+		f.token = strings.TrimSpace(string(data))
+		f.modTime = info.ModTime()
+	}
+
+	if f.token == "" || f.token == f.invalid {
+		return "", ErrNoTokens
+	}
+
+	return f.token, nil
+}
+
+// Invalidate marks `token` as not valid, it won't be returned until the file contents change
+func (f *FileTokenSource) Invalidate(token string) {
+	f.mu.Lock()
+	f.invalid = token
+	f.mu.Unlock()
+}
+
+//////////////////////////////////////////////////////////////////////
+// Pool of tokens
+//////////////////////////////////////////////////////////////////////
+
+// TokenPool rotates a set of tokens in round-robin manner.
+// Each token has its own rate limiter. Tokens rejected by VK API (error 5) are dropped from the pool.
+type TokenPool struct {
+	mu     sync.Mutex
+	tokens []*pooledToken
+	next   int
+}
+
+type pooledToken struct {
+	token   string
+	limiter *rateLimiter
+}
+
+// NewTokenPool creates TokenPool of `tokens` each allowed to send up to `rps` requests per second.
+// Rate limiting is disabled if `rps` is not positive.
+func NewTokenPool(tokens []string, rps float64) *TokenPool {
+	p := &TokenPool{tokens: make([]*pooledToken, len(tokens))}
+
+	for k, v := range tokens {
+		p.tokens[k] = &pooledToken{token: v, limiter: newRateLimiter(rps)}
+	}
+
+	return p
+}
+
+// Token returns the next token in the pool waiting for its rate limiter
+func (p *TokenPool) Token(ctx context.Context) (string, error) {
+	p.mu.Lock()
+
+	if len(p.tokens) == 0 {
+		p.mu.Unlock()
+		return "", ErrNoTokens
+	}
+
+	p.next %= len(p.tokens)
+	t := p.tokens[p.next]
+	p.next++
+	p.mu.Unlock()
+
+	if err := t.limiter.wait(ctx); err != nil {
+		return "", err
+	}
+
+	return t.token, nil
+}
+
+// Invalidate drops `token` from the pool
+func (p *TokenPool) Invalidate(token string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for k, v := range p.tokens {
+		if v.token == token {
+			p.tokens = append(p.tokens[:k], p.tokens[k+1:]...)
+
+			if p.next > k {
+				p.next--
+			}
+
+			return
+		}
+	}
+}
+
+// Len returns number of valid tokens in the pool
+func (p *TokenPool) Len() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return len(p.tokens)
+}
+
+// rateLimiter spaces requests evenly to send no more than the configured number of requests per second
+type rateLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+func newRateLimiter(rps float64) *rateLimiter {
+	if rps <= 0 {
+		return &rateLimiter{}
+	}
+
+	return &rateLimiter{interval: time.Duration(float64(time.Second) / rps)}
+}
+
+// wait blocks until the next request is allowed or `ctx` is done
+func (r *rateLimiter) wait(ctx context.Context) error {
+	if r.interval == 0 {
+		return nil
+	}
+
+	r.mu.Lock()
+	now := time.Now()
+	slot := r.next
+
+	if slot.Before(now) {
+		slot = now
+	}
+
+	r.next = slot.Add(r.interval)
+	r.mu.Unlock()
+
+	if delay := slot.Sub(now); delay > 0 {
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	return nil
+}
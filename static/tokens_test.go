@@ -0,0 +1,142 @@
+/*
+Copyright 2019 Konstantin Vasilev (burmuley@gmail.com)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package go_vkapi
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestTokenPool(t *testing.T) {
+	t.Run("TestRoundRobin", func(t *testing.T) {
+		p := NewTokenPool([]string{"a", "b", "c"}, 0)
+		got := ""
+
+		for i := 0; i < 4; i++ {
+			token, _ := p.Token(context.Background())
+			got += token
+		}
+
+		if got != "abca" {
+			t.Errorf("Token() sequence = %s, want abca", got)
+		}
+	})
+
+	t.Run("TestInvalidate", func(t *testing.T) {
+		p := NewTokenPool([]string{"a", "b"}, 0)
+		p.Invalidate("a")
+
+		if token, _ := p.Token(context.Background()); token != "b" || p.Len() != 1 {
+			t.Errorf("Token() = %s, Len() = %d, want b, 1", token, p.Len())
+		}
+
+		p.Invalidate("b")
+
+		if _, err := p.Token(context.Background()); err != ErrNoTokens {
+			t.Errorf("Token() error = %v, want %v", err, ErrNoTokens)
+		}
+	})
+
+	t.Run("TestRateLimit", func(t *testing.T) {
+		p := NewTokenPool([]string{"a"}, 20)
+		start := time.Now()
+
+		for i := 0; i < 3; i++ {
+			if _, err := p.Token(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+			t.Errorf("3 tokens at 20 rps taken in %s, want at least 100ms", elapsed)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		if _, err := p.Token(ctx); err != context.Canceled {
+			t.Errorf("Token() error = %v, want %v", err, context.Canceled)
+		}
+	})
+}
+
+func TestFileTokenSource(t *testing.T) {
+	fName := filepath.Join(t.TempDir(), "token")
+
+	if err := ioutil.WriteFile(fName, []byte("first\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	f := NewFileTokenSource(fName)
+
+	if token, err := f.Token(context.Background()); token != "first" || err != nil {
+		t.Errorf("Token() = %s, %v, want first, <nil>", token, err)
+	}
+
+	f.Invalidate("first")
+
+	if _, err := f.Token(context.Background()); err != ErrNoTokens {
+		t.Errorf("Token() error = %v, want %v", err, ErrNoTokens)
+	}
+
+	if err := ioutil.WriteFile(fName, []byte("second"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// make sure modification time changes on file systems with coarse timestamps
+	modTime := time.Now().Add(time.Second)
+
+	if err := os.Chtimes(fName, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+
+	if token, err := f.Token(context.Background()); token != "second" || err != nil {
+		t.Errorf("Token() = %s, %v, want second, <nil>", token, err)
+	}
+}
+
+func TestVKApi_TokenRotation(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("access_token") == "expired" {
+			fmt.Fprint(w, `{"error":{"error_code":5,"error_msg":"User authorization failed: access_token has expired."}}`)
+			return
+		}
+
+		fmt.Fprintf(w, `{"response":"%s"}`, r.FormValue("access_token"))
+	}))
+	defer srv.Close()
+
+	pool := NewTokenPool([]string{"expired", "valid"}, 0)
+	vk := NewApiWithTokenSource(pool)
+	vk.apiUrl = srv.URL + "/"
+
+	resp, err := vk.SendAPIRequest("users.get", map[string]interface{}{})
+
+	if err != nil || string(resp) != `"valid"` {
+		t.Errorf("SendAPIRequest() = %s, %v, want \"valid\", <nil>", resp, err)
+	}
+
+	if pool.Len() != 1 {
+		t.Errorf("pool size = %d, want 1", pool.Len())
+	}
+}
@@ -0,0 +1,91 @@
+/*
+Copyright 2019 Konstantin Vasilev (burmuley@gmail.com)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package upload
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Burmuley/go-vkapi/objects"
+	"github.com/Burmuley/go-vkapi/responses"
+)
+
+// docUpload is a response of upload server for documents
+type docUpload struct {
+	File string `json:"file"`
+}
+
+// Document uploads a document of the current user or community `groupId` (if not zero)
+func (u *Uploader) Document(ctx context.Context, groupId int, title string, tags []string, f File) (*objects.DocsDoc, error) {
+	server, err := u.Docs.GetuploadserverContext(ctx, groupId)
+	uploadUrl, err := uploadServer("docs.getUploadServer", server.UploadUrl, err)
+
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := u.saveDoc(ctx, uploadUrl, title, tags, f)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if doc.Type != "doc" {
+		return nil, fmt.Errorf("`docs.save` returned `%s` instead of a document", doc.Type)
+	}
+
+	return &doc.Doc, nil
+}
+
+// VoiceMessage uploads an audio message (OGG or MP3) to send it in a message to `peerId`
+func (u *Uploader) VoiceMessage(ctx context.Context, peerId int, f File) (*objects.MessagesAudioMessage, error) {
+	server, err := u.Docs.GetmessagesuploadserverContext(ctx, "audio_message", peerId)
+	uploadUrl, err := uploadServer("docs.getMessagesUploadServer", server.UploadUrl, err)
+
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := u.saveDoc(ctx, uploadUrl, "", nil, f)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if doc.Type != "audio_message" {
+		return nil, fmt.Errorf("`docs.save` returned `%s` instead of an audio message", doc.Type)
+	}
+
+	return &doc.AudioMessage, nil
+}
+
+// saveDoc sends the file to `uploadUrl` and saves it with `docs.save`
+func (u *Uploader) saveDoc(ctx context.Context, uploadUrl, title string, tags []string, f File) (*responses.DocsSave, error) {
+	var up docUpload
+
+	if err := u.send(ctx, uploadUrl, "file", f, &up); err != nil {
+		return nil, err
+	}
+
+	doc, err := u.Docs.SaveContext(ctx, up.File, title, strings.Join(tags, ","))
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &doc, nil
+}
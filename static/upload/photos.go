@@ -0,0 +1,138 @@
+/*
+Copyright 2019 Konstantin Vasilev (burmuley@gmail.com)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package upload
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/Burmuley/go-vkapi/objects"
+)
+
+// photoUpload is a response of upload server for photos
+type photoUpload struct {
+	Server int    `json:"server"`
+	Photo  string `json:"photo"`
+	Hash   string `json:"hash"`
+}
+
+// WallPhoto uploads a photo to post it on the wall of the current user or community `groupId` (if not zero)
+func (u *Uploader) WallPhoto(ctx context.Context, groupId int, f File) ([]objects.PhotosPhoto, error) {
+	server, err := u.Photos.GetwalluploadserverContext(ctx, groupId)
+	uploadUrl, err := uploadServer("photos.getWallUploadServer", server.UploadUrl, err)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var up photoUpload
+
+	if err := u.send(ctx, uploadUrl, "photo", f, &up); err != nil {
+		return nil, err
+	}
+
+	photos, err := u.Photos.SavewallphotoContext(ctx, 0, groupId, up.Photo, up.Server, up.Hash)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return photos, nil
+}
+
+// MessagePhoto uploads a photo to send it in a message to `peerId` (may be zero for user tokens)
+func (u *Uploader) MessagePhoto(ctx context.Context, peerId int, f File) ([]objects.PhotosPhoto, error) {
+	server, err := u.Photos.GetmessagesuploadserverContext(ctx, peerId)
+	uploadUrl, err := uploadServer("photos.getMessagesUploadServer", server.UploadUrl, err)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var up photoUpload
+
+	if err := u.send(ctx, uploadUrl, "photo", f, &up); err != nil {
+		return nil, err
+	}
+
+	photos, err := u.Photos.SavemessagesphotoContext(ctx, up.Photo, up.Server, up.Hash)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return photos, nil
+}
+
+// OwnerPhoto is a result of saving a profile or community main photo
+type OwnerPhoto struct {
+	PhotoHash string `json:"photo_hash"`
+	PhotoSrc  string `json:"photo_src"`
+	PostId    int    `json:"post_id"` // ID of the post about the new photo
+}
+
+// OwnerPhoto uploads the main photo of the current user or `ownerId` (negative for a community)
+func (u *Uploader) OwnerPhoto(ctx context.Context, ownerId int, f File) (*OwnerPhoto, error) {
+	server, err := u.Photos.GetownerphotouploadserverContext(ctx, ownerId)
+	uploadUrl, err := uploadServer("photos.getOwnerPhotoUploadServer", server.UploadUrl, err)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var up photoUpload
+
+	if err := u.send(ctx, uploadUrl, "photo", f, &up); err != nil {
+		return nil, err
+	}
+
+	saved, err := u.Photos.SaveownerphotoContext(ctx, strconv.Itoa(up.Server), up.Hash, up.Photo)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &OwnerPhoto{PhotoHash: saved.PhotoHash, PhotoSrc: saved.PhotoSrc, PostId: saved.PostId}, nil
+}
+
+// CoverCrop is an area of the uploaded image to use as a community cover, whole image is used if zero
+type CoverCrop struct {
+	X, Y, X2, Y2 int
+}
+
+// CoverPhoto uploads the cover of community `groupId`, returns copies of the cover in different sizes
+func (u *Uploader) CoverPhoto(ctx context.Context, groupId int, crop CoverCrop, f File) ([]objects.BaseImage, error) {
+	server, err := u.Photos.GetownercoverphotouploadserverContext(ctx, groupId, crop.X, crop.Y, crop.X2, crop.Y2)
+	uploadUrl, err := uploadServer("photos.getOwnerCoverPhotoUploadServer", server.UploadUrl, err)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var up photoUpload
+
+	if err := u.send(ctx, uploadUrl, "photo", f, &up); err != nil {
+		return nil, err
+	}
+
+	cover, err := u.Photos.SaveownercoverphotoContext(ctx, up.Hash, up.Photo)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return cover.Images, nil
+}
@@ -0,0 +1,76 @@
+/*
+Copyright 2019 Konstantin Vasilev (burmuley@gmail.com)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package upload
+
+import (
+	"context"
+
+	"github.com/Burmuley/go-vkapi/objects"
+)
+
+// StoryParams describes a story to upload
+type StoryParams struct {
+	GroupId  int    // community to publish the story in, current user if zero
+	ReplyTo  string // `<owner_id>_<story_id>` of the story to reply to
+	LinkText string // text of the link button
+	LinkUrl  string // URL of the link button
+	Hidden   bool   // don't publish the story in the news feed
+}
+
+// StoryPhoto uploads a photo story
+func (u *Uploader) StoryPhoto(ctx context.Context, story StoryParams, f File) ([]objects.StoriesStory, error) {
+	server, err := u.Stories.GetphotouploadserverContext(ctx, !story.Hidden, nil, story.ReplyTo, story.LinkText, story.LinkUrl, story.GroupId)
+	uploadUrl, err := uploadServer("stories.getPhotoUploadServer", server.UploadUrl, err)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return u.saveStory(ctx, uploadUrl, "file", f)
+}
+
+// StoryVideo uploads a video story
+func (u *Uploader) StoryVideo(ctx context.Context, story StoryParams, f File) ([]objects.StoriesStory, error) {
+	server, err := u.Stories.GetvideouploadserverContext(ctx, !story.Hidden, nil, story.ReplyTo, story.LinkText, story.LinkUrl, story.GroupId)
+	uploadUrl, err := uploadServer("stories.getVideoUploadServer", server.UploadUrl, err)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return u.saveStory(ctx, uploadUrl, "video_file", f)
+}
+
+// saveStory sends the file as `field` to `uploadUrl` and saves the story
+func (u *Uploader) saveStory(ctx context.Context, uploadUrl, field string, f File) ([]objects.StoriesStory, error) {
+	var up struct {
+		Response struct {
+			UploadResult string `json:"upload_result"`
+		} `json:"response"`
+	}
+
+	if err := u.send(ctx, uploadUrl, field, f, &up); err != nil {
+		return nil, err
+	}
+
+	saved, err := u.Stories.SaveContext(ctx, []string{up.Response.UploadResult})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return saved.Items, nil
+}
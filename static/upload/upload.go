@@ -0,0 +1,181 @@
+/*
+Copyright 2019 Konstantin Vasilev (burmuley@gmail.com)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package upload implements file upload workflows (https://vk.com/dev/upload_files):
+// getting an upload server, sending a file to it and saving the uploaded file
+package upload
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+
+	"github.com/Burmuley/go-vkapi"
+)
+
+// ProgressFunc is called while a file is being sent with number of bytes sent so far
+// and total file size (`-1` if the size is unknown)
+type ProgressFunc func(sent, total int64)
+
+// File is a file to upload, its contents is streamed from `Reader`
+type File struct {
+	Name     string       // file name, VK uses its extension to detect the file type
+	Reader   io.Reader    // file contents
+	Size     int64        // file size passed to `Progress`, `0` if unknown
+	Progress ProgressFunc // called while the file is being sent, may be nil
+}
+
+// Uploader uploads files using the API method groups to get upload servers and save uploaded files
+type Uploader struct {
+	Photos     *go_vkapi.Photos  // used to upload photos
+	Docs       *go_vkapi.Docs    // used to upload documents and audio messages
+	Videos     *go_vkapi.Video   // used to upload videos
+	Stories    *go_vkapi.Stories // used to upload stories
+	HTTPClient *http.Client      // client to send files to upload servers, `http.DefaultClient` if nil
+}
+
+// NewUploader creates Uploader using `vk` to call API methods
+func NewUploader(vk *go_vkapi.VKApi) *Uploader {
+	return &Uploader{
+		Photos:  &go_vkapi.Photos{VKApi: vk},
+		Docs:    &go_vkapi.Docs{VKApi: vk},
+		Videos:  &go_vkapi.Video{VKApi: vk},
+		Stories: &go_vkapi.Stories{VKApi: vk},
+	}
+}
+
+// Error is returned when upload server rejects a file
+type Error struct {
+	Message string
+}
+
+func (e Error) Error() string {
+	return fmt.Sprintf("UPLOAD ERROR! Message: %s", e.Message)
+}
+
+// uploadServer checks the result of `method` returned an upload server URL
+func uploadServer(method, uploadUrl string, err error) (string, error) {
+	if err != nil {
+		return "", err
+	}
+
+	if uploadUrl == "" {
+		return "", fmt.Errorf("no upload URL in `%s` response", method)
+	}
+
+	return uploadUrl, nil
+}
+
+// send streams file `f` as multipart form field `field` to `uploadUrl` and decodes the response into `out`
+func (u *Uploader) send(ctx context.Context, uploadUrl, field string, f File, out interface{}) error {
+	pr, pw := io.Pipe()
+	form := multipart.NewWriter(pw)
+
+	go func() {
+		part, err := form.CreateFormFile(field, f.Name)
+
+		if err == nil {
+			_, err = io.Copy(part, &progressReader{r: f.Reader, total: f.Size, progress: f.Progress})
+		}
+
+		if err == nil {
+			err = form.Close()
+		}
+
+		pw.CloseWithError(err)
+	}()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, uploadUrl, pr)
+
+	if err != nil {
+		pr.Close()
+		return err
+	}
+
+	req.Header.Set("Content-Type", form.FormDataContentType())
+
+	client := u.HTTPClient
+
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+
+	// unblock the writing goroutine if the request failed before the body was read
+	pr.Close()
+
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+
+	if err != nil {
+		return err
+	}
+
+	var uErr struct {
+		Error json.RawMessage `json:"error"`
+	}
+
+	if err := json.Unmarshal(body, &uErr); err != nil {
+		return err
+	}
+
+	if len(uErr.Error) > 0 {
+		var msg string
+
+		if json.Unmarshal(uErr.Error, &msg) != nil {
+			msg = string(uErr.Error)
+		}
+
+		return Error{Message: msg}
+	}
+
+	return json.Unmarshal(body, out)
+}
+
+// progressReader reports number of bytes read from `r`
+type progressReader struct {
+	r        io.Reader
+	sent     int64
+	total    int64
+	progress ProgressFunc
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+
+	if n > 0 && p.progress != nil {
+		p.sent += int64(n)
+		total := p.total
+
+		if total <= 0 {
+			total = -1
+		}
+
+		p.progress(p.sent, total)
+	}
+
+	return n, err
+}
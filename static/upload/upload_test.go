@@ -0,0 +1,253 @@
+/*
+Copyright 2019 Konstantin Vasilev (burmuley@gmail.com)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package upload
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Burmuley/go-vkapi"
+	"github.com/Burmuley/go-vkapi/objects"
+)
+
+// fakeServer serves API methods from `methods` and accepts files at `/upload`
+type fakeServer struct {
+	*httptest.Server
+	methods map[string]string // API responses by method name
+	upload  string            // upload server response
+	calls   []string          // API methods called with their parameters and uploaded files
+}
+
+func newFakeServer(methods map[string]string, upload string) *fakeServer {
+	f := &fakeServer{methods: methods, upload: upload}
+	f.Server = httptest.NewServer(http.HandlerFunc(f.handle))
+
+	return f
+}
+
+func (f *fakeServer) handle(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/upload" {
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		for field, files := range r.MultipartForm.File {
+			file, _ := files[0].Open()
+			data, _ := ioutil.ReadAll(file)
+			f.calls = append(f.calls, fmt.Sprintf("upload %s=%s:%s", field, files[0].Filename, data))
+		}
+
+		fmt.Fprint(w, f.upload)
+
+		return
+	}
+
+	method := strings.TrimPrefix(r.URL.Path, "/method/")
+	r.ParseForm()
+	r.Form.Del("v")
+	r.Form.Del("access_token")
+	f.calls = append(f.calls, method+" "+r.Form.Encode())
+
+	resp, ok := f.methods[method]
+
+	if !ok {
+		fmt.Fprint(w, `{"error":{"error_code":3,"error_msg":"Unknown method passed"}}`)
+		return
+	}
+
+	fmt.Fprintf(w, `{"response":%s}`, strings.ReplaceAll(resp, "UPLOAD_URL", f.URL+"/upload"))
+}
+
+func TestUploader(t *testing.T) {
+	tests := []struct {
+		name      string
+		methods   map[string]string
+		upload    string
+		run       func(u *Uploader, f File) (interface{}, error)
+		want      interface{}
+		wantCalls []string
+		wantErr   bool
+	}{
+		{
+			"TestWallPhoto",
+			map[string]string{
+				"photos.getWallUploadServer": `{"upload_url":"UPLOAD_URL","album_id":1,"user_id":2}`,
+				"photos.saveWallPhoto":       `[{"id":10,"owner_id":-5}]`,
+			},
+			`{"server":123,"photo":"[data]","hash":"abc"}`,
+			func(u *Uploader, f File) (interface{}, error) {
+				return u.WallPhoto(context.Background(), 5, f)
+			},
+			[]objects.PhotosPhoto{{Id: 10, OwnerId: -5}},
+			[]string{
+				"photos.getWallUploadServer group_id=5",
+				"upload photo=test.jpg:contents",
+				"photos.saveWallPhoto group_id=5&hash=abc&photo=%5Bdata%5D&server=123",
+			},
+			false,
+		},
+		{
+			"TestCoverPhoto",
+			map[string]string{
+				"photos.getOwnerCoverPhotoUploadServer": `{"upload_url":"UPLOAD_URL"}`,
+				"photos.saveOwnerCoverPhoto":            `{"images":[{"url":"https://example.com/1.jpg","width":1590,"height":400}]}`,
+			},
+			`{"photo":"[data]","hash":"abc"}`,
+			func(u *Uploader, f File) (interface{}, error) {
+				return u.CoverPhoto(context.Background(), 5, CoverCrop{X2: 1590, Y2: 400}, f)
+			},
+			[]objects.BaseImage{{Url: "https://example.com/1.jpg", Width: 1590, Height: 400}},
+			[]string{
+				"photos.getOwnerCoverPhotoUploadServer crop_x2=1590&crop_y2=400&group_id=5",
+				"upload photo=test.jpg:contents",
+				"photos.saveOwnerCoverPhoto hash=abc&photo=%5Bdata%5D",
+			},
+			false,
+		},
+		{
+			"TestVoiceMessage",
+			map[string]string{
+				"docs.getMessagesUploadServer": `{"upload_url":"UPLOAD_URL"}`,
+				"docs.save":                    `{"type":"audio_message","audio_message":{"id":1,"owner_id":2,"duration":3}}`,
+			},
+			`{"file":"file-data"}`,
+			func(u *Uploader, f File) (interface{}, error) {
+				return u.VoiceMessage(context.Background(), 2000000001, f)
+			},
+			&objects.MessagesAudioMessage{Id: 1, OwnerId: 2, Duration: 3},
+			[]string{
+				"docs.getMessagesUploadServer peer_id=2000000001&type=audio_message",
+				"upload file=test.jpg:contents",
+				"docs.save file=file-data",
+			},
+			false,
+		},
+		{
+			"TestDocumentWrongType",
+			map[string]string{
+				"docs.getUploadServer": `{"upload_url":"UPLOAD_URL"}`,
+				"docs.save":            `{"type":"graffiti","graffiti":{}}`,
+			},
+			`{"file":"file-data"}`,
+			func(u *Uploader, f File) (interface{}, error) {
+				return u.Document(context.Background(), 0, "title", []string{"a", "b"}, f)
+			},
+			nil,
+			[]string{
+				"docs.getUploadServer ",
+				"upload file=test.jpg:contents",
+				"docs.save file=file-data&tags=a%2Cb&title=title",
+			},
+			true,
+		},
+		{
+			"TestVideo",
+			map[string]string{
+				"video.save": `{"upload_url":"UPLOAD_URL","owner_id":2,"title":"name"}`,
+			},
+			`{"size":8,"video_id":77}`,
+			func(u *Uploader, f File) (interface{}, error) {
+				return u.Video(context.Background(), VideoParams{Name: "name", Wallpost: true}, f)
+			},
+			&objects.VideoSaveResult{UploadUrl: "UPLOAD_URL", OwnerId: 2, Title: "name", VideoId: 77},
+			[]string{
+				"video.save compression=0&is_private=0&name=name&no_comments=0&repeat=0&wallpost=1",
+				"upload video_file=test.jpg:contents",
+			},
+			false,
+		},
+		{
+			"TestStoryPhoto",
+			map[string]string{
+				"stories.getPhotoUploadServer": `{"upload_url":"UPLOAD_URL","user_ids":[]}`,
+				"stories.save":                 `{"count":1,"items":[{"id":3,"owner_id":2}]}`,
+			},
+			`{"response":{"upload_result":"result"}}`,
+			func(u *Uploader, f File) (interface{}, error) {
+				return u.StoryPhoto(context.Background(), StoryParams{}, f)
+			},
+			[]objects.StoriesStory{{Id: 3, OwnerId: 2}},
+			[]string{
+				"stories.getPhotoUploadServer add_to_news=1",
+				"upload file=test.jpg:contents",
+				"stories.save upload_results=result",
+			},
+			false,
+		},
+		{
+			"TestUploadError",
+			map[string]string{
+				"photos.getMessagesUploadServer": `{"upload_url":"UPLOAD_URL"}`,
+			},
+			`{"error":"ERR_UPLOAD_BAD_IMAGE_SIZE"}`,
+			func(u *Uploader, f File) (interface{}, error) {
+				return u.MessagePhoto(context.Background(), 0, f)
+			},
+			nil,
+			[]string{
+				"photos.getMessagesUploadServer ",
+				"upload photo=test.jpg:contents",
+			},
+			true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newFakeServer(tt.methods, tt.upload)
+			defer srv.Close()
+
+			if video, ok := tt.want.(*objects.VideoSaveResult); ok {
+				video.UploadUrl = srv.URL + "/upload"
+			}
+
+			var progress []int64
+
+			u := NewUploader(go_vkapi.NewApiWithToken("token", go_vkapi.WithApiUrl(srv.URL+"/method/")))
+			f := File{
+				Name:     "test.jpg",
+				Reader:   strings.NewReader("contents"),
+				Size:     8,
+				Progress: func(sent, total int64) { progress = append(progress, sent, total) },
+			}
+
+			got, err := tt.run(u, f)
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("upload error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("upload = %+v, want %+v", got, tt.want)
+			}
+
+			if !reflect.DeepEqual(srv.calls, tt.wantCalls) {
+				t.Errorf("calls = %q, want %q", srv.calls, tt.wantCalls)
+			}
+
+			if !reflect.DeepEqual(progress, []int64{8, 8}) {
+				t.Errorf("progress = %v, want [8 8]", progress)
+			}
+		})
+	}
+}
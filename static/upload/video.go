@@ -0,0 +1,58 @@
+/*
+Copyright 2019 Konstantin Vasilev (burmuley@gmail.com)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package upload
+
+import (
+	"context"
+
+	"github.com/Burmuley/go-vkapi/objects"
+)
+
+// VideoParams describes a video to upload
+type VideoParams struct {
+	Name        string
+	Description string
+	GroupId     int  // community to upload the video to, current user if zero
+	AlbumId     int  // album to add the video to
+	IsPrivate   bool // the video is sent in a private message and won't be shown in the video list
+	Wallpost    bool // post the video on the wall after upload
+}
+
+// Video uploads a video file, returns the result of `video.save` with the ID of the uploaded video
+func (u *Uploader) Video(ctx context.Context, video VideoParams, f File) (*objects.VideoSaveResult, error) {
+	saved, err := u.Videos.SaveContext(ctx, video.Name, video.Description, video.IsPrivate, video.Wallpost, "",
+		video.GroupId, video.AlbumId, nil, nil, false, false, false)
+
+	if err != nil {
+		return nil, err
+	}
+
+	result := objects.VideoSaveResult(saved)
+
+	var up struct {
+		VideoId int `json:"video_id"`
+	}
+
+	if err := u.send(ctx, result.UploadUrl, "video_file", f, &up); err != nil {
+		return nil, err
+	}
+
+	if up.VideoId != 0 {
+		result.VideoId = up.VideoId
+	}
+
+	return &result, nil
+}
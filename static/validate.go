@@ -0,0 +1,282 @@
+/*
+Copyright 2019 Konstantin Vasilev (burmuley@gmail.com)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package go_vkapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Violation is a mismatch between an API response and its schema definition
+type Violation struct {
+	Method   string // API method name
+	Pointer  string // JSON pointer to the mismatched value, e.g. `/items/0/id`
+	Expected string // expected type, `enum`, `oneOf` or `required`
+	Actual   string // actual JSON type, `missing` for absent required properties
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("%s: %s: expected %s, got %s", v.Method, v.Pointer, v.Expected, v.Actual)
+}
+
+// ViolationHandler is called for each response value not matching the schema
+type ViolationHandler func(v Violation)
+
+// WithResponseValidation enables debug mode validating every response against its definition in responses
+// schema embedded into the SDK. Violations are reported to `handler`, responses are returned as usual.
+// Validation is expensive and isn't meant for production.
+func WithResponseValidation(handler ViolationHandler) Option {
+	return WithMiddleware(ResponseValidation(handler))
+}
+
+// ResponseValidation returns middleware validating successful responses, see `WithResponseValidation`
+func ResponseValidation(handler ViolationHandler) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, req *Request) (*Response, error) {
+			resp, err := RawResponse(next)(ctx, req)
+
+			if err == nil {
+				validateResponse(req.Method, fmt.Sprint(req.Params["extended"]) == "1", resp.Raw, handler)
+			}
+
+			return resp, err
+		}
+	}
+}
+
+// schemaNode is a compact schema definition, see `responseSchemas`
+type schemaNode struct {
+	Type       []string               `json:"type"`
+	Ref        string                 `json:"$ref"`
+	Properties map[string]*schemaNode `json:"properties"`
+	Required   []string               `json:"required"`
+	Items      *schemaNode            `json:"items"`
+	Enum       []interface{}          `json:"enum"`
+	AllOf      []*schemaNode          `json:"allOf"`
+	OneOf      []*schemaNode          `json:"oneOf"`
+}
+
+var (
+	schemaDefs     map[string]*schemaNode
+	schemaDefsErr  error
+	schemaDefsOnce sync.Once
+)
+
+// loadSchemaDefs parses embedded schema definitions once
+func loadSchemaDefs() (map[string]*schemaNode, error) {
+	schemaDefsOnce.Do(func() {
+		// numbers are kept as is to compare enum values with response values
+		dec := json.NewDecoder(strings.NewReader(responseSchemas))
+		dec.UseNumber()
+		schemaDefsErr = dec.Decode(&schemaDefs)
+	})
+
+	return schemaDefs, schemaDefsErr
+}
+
+// validateResponse checks raw `response` of `method` against its schema definition reporting violations to `handler`.
+// Extended response definition is used if `extended` is set.
+func validateResponse(method string, extended bool, response []byte, handler ViolationHandler) {
+	defs, err := loadSchemaDefs()
+	names, ok := methodResponses[method]
+
+	if err != nil || !ok {
+		return
+	}
+
+	ref := names[0]
+
+	if extended && len(names[1]) > 0 {
+		ref = names[1]
+	}
+
+	def, ok := defs[ref]
+
+	if !ok {
+		return
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(response))
+	dec.UseNumber()
+
+	var value interface{}
+
+	if err := dec.Decode(&value); err != nil {
+		return
+	}
+
+	v := &validator{defs: defs}
+	v.validate("", def, value)
+
+	for _, vl := range v.violations {
+		vl.Method = method
+		handler(vl)
+	}
+}
+
+// validator collects violations of a value
+type validator struct {
+	defs       map[string]*schemaNode
+	violations []Violation
+	following  map[refAt]bool // references being followed, to stop on cycles
+}
+
+// refAt is a reference followed to validate a value at JSON pointer
+type refAt struct {
+	pointer string
+	ref     string
+}
+
+// validate checks `value` located at JSON `pointer` against `node`
+func (v *validator) validate(pointer string, node *schemaNode, value interface{}) {
+	if node == nil {
+		return
+	}
+
+	if len(node.Ref) > 0 {
+		// a reference leading back to itself for the same value would never end
+		key := refAt{pointer, node.Ref}
+
+		if v.following[key] {
+			return
+		}
+
+		if v.following == nil {
+			v.following = make(map[refAt]bool)
+		}
+
+		v.following[key] = true
+		v.validate(pointer, v.defs[node.Ref], value)
+		delete(v.following, key)
+
+		return
+	}
+
+	for _, n := range node.AllOf {
+		v.validate(pointer, n, value)
+	}
+
+	if len(node.OneOf) > 0 && !v.matchesOneOf(pointer, node.OneOf, value) {
+		v.report(pointer, "oneOf", value)
+	}
+
+	if len(node.Enum) > 0 && !inEnum(node.Enum, value) {
+		v.report(pointer, "enum", value)
+		return
+	}
+
+	if len(node.Type) > 0 && !matchesType(node.Type, value) {
+		v.report(pointer, strings.Join(node.Type, "|"), value)
+		return
+	}
+
+	switch val := value.(type) {
+	case map[string]interface{}:
+		for _, r := range node.Required {
+			if _, ok := val[r]; !ok {
+				v.violations = append(v.violations, Violation{Pointer: pointer + "/" + escapePointer(r), Expected: "required", Actual: "missing"})
+			}
+		}
+
+		for k, p := range node.Properties {
+			if pv, ok := val[k]; ok {
+				v.validate(pointer+"/"+escapePointer(k), p, pv)
+			}
+		}
+	case []interface{}:
+		for i, item := range val {
+			v.validate(pointer+"/"+strconv.Itoa(i), node.Items, item)
+		}
+	}
+}
+
+// matchesOneOf checks if `value` matches at least one of `nodes`
+func (v *validator) matchesOneOf(pointer string, nodes []*schemaNode, value interface{}) bool {
+	for _, n := range nodes {
+		sub := &validator{defs: v.defs, following: v.following}
+		sub.validate(pointer, n, value)
+
+		if len(sub.violations) == 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// report adds a violation of `expected` at `pointer`
+func (v *validator) report(pointer, expected string, value interface{}) {
+	v.violations = append(v.violations, Violation{Pointer: pointer, Expected: expected, Actual: jsonType(value)})
+}
+
+// matchesType checks if `value` is of one of schema `types`
+func matchesType(types []string, value interface{}) bool {
+	actual := jsonType(value)
+
+	for _, t := range types {
+		if t == actual || (t == "number" && actual == "integer") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// inEnum checks if `value` is one of `enum` values
+func inEnum(enum []interface{}, value interface{}) bool {
+	for _, e := range enum {
+		if fmt.Sprint(e) == fmt.Sprint(value) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// jsonType returns schema type of a decoded JSON `value`
+func jsonType(value interface{}) string {
+	switch val := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case json.Number:
+		if _, err := val.Int64(); err == nil {
+			return "integer"
+		}
+
+		return "number"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+
+	return fmt.Sprintf("%T", value)
+}
+
+// escapePointer escapes a JSON pointer reference token
+func escapePointer(s string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(s)
+}
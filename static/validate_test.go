@@ -0,0 +1,120 @@
+/*
+Copyright 2019 Konstantin Vasilev (burmuley@gmail.com)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package go_vkapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func Test_validator(t *testing.T) {
+	var defs map[string]*schemaNode
+
+	schema := `{
+		"objects.json#/definitions/base_bool_int": {"type": ["integer"], "enum": [0, 1]},
+		"objects.json#/definitions/users_user": {"type": ["object"], "required": ["id"], "properties": {
+			"id": {"type": ["integer"]},
+			"name": {"type": ["string"]},
+			"rate": {"type": ["number"]},
+			"verified": {"$ref": "objects.json#/definitions/base_bool_int"},
+			"counter": {"type": ["integer", "string"]},
+			"friends": {"type": ["array"], "items": {"$ref": "objects.json#/definitions/users_user"}},
+			"link": {"oneOf": [{"type": ["string"]}, {"type": ["object"]}]}
+		}}
+	}`
+
+	dec := json.NewDecoder(bytes.NewReader([]byte(schema)))
+	dec.UseNumber()
+
+	if err := dec.Decode(&defs); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		response string
+		want     []Violation
+	}{
+		{"TestValid", `{"id":1,"name":"a","rate":1.5,"verified":1,"counter":"5","friends":[{"id":2}],"link":"a","extra":true}`, nil},
+		{"TestType", `{"id":"1","rate":2}`, []Violation{{Pointer: "/id", Expected: "integer", Actual: "string"}}},
+		{"TestNumber", `{"id":1.5}`, []Violation{{Pointer: "/id", Expected: "integer", Actual: "number"}}},
+		{"TestEnum", `{"id":1,"verified":2}`, []Violation{{Pointer: "/verified", Expected: "enum", Actual: "integer"}}},
+		{"TestRequired", `{"name":"a"}`, []Violation{{Pointer: "/id", Expected: "required", Actual: "missing"}}},
+		{"TestNested", `{"id":1,"friends":[{"id":2},{"id":null}]}`, []Violation{{Pointer: "/friends/1/id", Expected: "integer", Actual: "null"}}},
+		{"TestOneOf", `{"id":1,"link":5}`, []Violation{{Pointer: "/link", Expected: "oneOf", Actual: "integer"}}},
+		{"TestRoot", `[]`, []Violation{{Pointer: "", Expected: "object", Actual: "array"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var value interface{}
+
+			dec := json.NewDecoder(bytes.NewReader([]byte(tt.response)))
+			dec.UseNumber()
+
+			if err := dec.Decode(&value); err != nil {
+				t.Fatal(err)
+			}
+
+			v := &validator{defs: defs}
+			v.validate("", defs["objects.json#/definitions/users_user"], value)
+
+			if !reflect.DeepEqual(v.violations, tt.want) {
+				t.Errorf("violations = %v, want %v", v.violations, tt.want)
+			}
+		})
+	}
+}
+
+func Test_validatorRefCycle(t *testing.T) {
+	var defs map[string]*schemaNode
+
+	// references leading back to themselves without descending into the value
+	schema := `{
+		"responses.json#/definitions/base_ok_response": {"$ref": "responses.json#/definitions/base_ok_response"},
+		"objects.json#/definitions/a": {"oneOf": [{"$ref": "objects.json#/definitions/b"}, {"type": ["string"]}]},
+		"objects.json#/definitions/b": {"allOf": [{"$ref": "objects.json#/definitions/a"}], "type": ["integer"]}
+	}`
+
+	if err := json.Unmarshal([]byte(schema), &defs); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		ref   string
+		value interface{}
+		want  []Violation
+	}{
+		{"TestSelf", "responses.json#/definitions/base_ok_response", json.Number("1"), nil},
+		{"TestMutual", "objects.json#/definitions/a", json.Number("1"), nil},
+		{"TestMutualViolation", "objects.json#/definitions/a", true, []Violation{{Pointer: "", Expected: "oneOf", Actual: "boolean"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := &validator{defs: defs}
+			v.validate("", defs[tt.ref], tt.value)
+
+			if !reflect.DeepEqual(v.violations, tt.want) {
+				t.Errorf("violations = %v, want %v", v.violations, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,278 @@
+/*
+Copyright 2019 Konstantin Vasilev (burmuley@gmail.com)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vkapitest
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/Burmuley/go-vkapi"
+)
+
+// CassetteMode defines if Cassette records or replays interactions
+type CassetteMode int
+
+const (
+	// ModeReplay serves recorded interactions failing on unmatched requests
+	ModeReplay CassetteMode = iota
+	// ModeRecord sends requests and records interactions
+	ModeRecord
+)
+
+// Value recorded instead of sensitive parameters and response fields
+const redacted = "[REDACTED]"
+
+// Interaction is a recorded request/response pair
+type Interaction struct {
+	Method   string `json:"method"`   // API method name
+	Params   string `json:"params"`   // normalized request parameters: sorted by name with sensitive values redacted
+	Status   int    `json:"status"`   // HTTP status code
+	Response string `json:"response"` // response body with sensitive fields (e.g. OAuth `access_token`) redacted
+}
+
+// Cassette is an `http.RoundTripper` recording VK API interactions to a JSON file and replaying them.
+// Requests are matched by method name and normalized parameters, so parameters order doesn't matter.
+// Interactions with the same request are replayed in order they were recorded.
+type Cassette struct {
+	Path      string
+	Mode      CassetteMode
+	Transport http.RoundTripper // sends requests in record mode, `http.DefaultTransport` if nil
+
+	mu           sync.Mutex
+	interactions []Interaction
+	used         []bool
+}
+
+// Cassette file structure
+type cassetteFile struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// NewCassette creates Cassette stored at `path`. In replay mode interactions are loaded from the file,
+// in record mode the file is overwritten with interactions as they are recorded.
+func NewCassette(path string, mode CassetteMode) (*Cassette, error) {
+	c := &Cassette{Path: path, Mode: mode}
+
+	if mode == ModeRecord {
+		return c, c.save()
+	}
+
+	b, err := os.ReadFile(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var f cassetteFile
+
+	if err := json.Unmarshal(b, &f); err != nil {
+		return nil, fmt.Errorf("cassette %s: %w", path, err)
+	}
+
+	c.interactions = f.Interactions
+	c.used = make([]bool, len(f.Interactions))
+
+	return c, nil
+}
+
+// Client returns HTTP client using the cassette, pass it to `go_vkapi.WithHTTPClient`
+func (c *Cassette) Client() *http.Client {
+	return &http.Client{Transport: c}
+}
+
+// RoundTrip implements `http.RoundTripper`
+func (c *Cassette) RoundTrip(req *http.Request) (*http.Response, error) {
+	method, params, err := normalizeRequest(req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if c.Mode == ModeRecord {
+		return c.record(req, method, params)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for i, in := range c.interactions {
+		if !c.used[i] && in.Method == method && in.Params == params {
+			c.used[i] = true
+
+			return &http.Response{
+				Status:        fmt.Sprintf("%d %s", in.Status, http.StatusText(in.Status)),
+				StatusCode:    in.Status,
+				Proto:         "HTTP/1.1",
+				ProtoMajor:    1,
+				ProtoMinor:    1,
+				Header:        http.Header{"Content-Type": {"application/json; charset=utf-8"}},
+				Body:          io.NopCloser(strings.NewReader(in.Response)),
+				ContentLength: int64(len(in.Response)),
+				Request:       req,
+			}, nil
+		}
+	}
+
+	return nil, fmt.Errorf("cassette %s: no recorded interaction for `%s` with parameters `%s`", c.Path, method, params)
+}
+
+// record sends `req` and records the interaction
+func (c *Cassette) record(req *http.Request, method, params string) (*http.Response, error) {
+	transport := c.Transport
+
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	resp, err := transport.RoundTrip(req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if err != nil {
+		return nil, err
+	}
+
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.interactions = append(c.interactions, Interaction{Method: method, Params: params, Status: resp.StatusCode, Response: string(redactBody(body))})
+
+	return resp, c.save()
+}
+
+// save writes recorded interactions to the file, must be called with `mu` locked in record mode
+func (c *Cassette) save() error {
+	f := cassetteFile{Interactions: c.interactions}
+
+	if f.Interactions == nil {
+		f.Interactions = []Interaction{}
+	}
+
+	b, err := json.MarshalIndent(f, "", "  ")
+
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(c.Path, b, 0644)
+}
+
+// normalizeRequest returns API method name and parameters of `req` from URL query and form body
+// encoded in order of names with sensitive values redacted. VKScript `code` of `execute` is replaced
+// with its hash, so different scripts are told apart. The request body is restored to be sent.
+func normalizeRequest(req *http.Request) (string, string, error) {
+	params := req.URL.Query()
+
+	if req.Body != nil {
+		body, err := io.ReadAll(req.Body)
+		req.Body.Close()
+
+		if err != nil {
+			return "", "", err
+		}
+
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		form, err := url.ParseQuery(string(body))
+
+		if err != nil {
+			return "", "", err
+		}
+
+		for k, v := range form {
+			params[k] = append(params[k], v...)
+		}
+	}
+
+	method := path.Base(req.URL.Path)
+
+	for p := range params {
+		switch {
+		case method == "execute" && p == "code":
+			sum := sha256.Sum256([]byte(params.Get(p)))
+			params.Set(p, "sha256:"+hex.EncodeToString(sum[:]))
+		case go_vkapi.IsSensitiveParam(p):
+			params.Set(p, redacted)
+		}
+	}
+
+	return method, params.Encode(), nil
+}
+
+// redactBody returns JSON `body` with string values of sensitive fields redacted.
+// Bodies which are not JSON or have nothing to redact are returned as is.
+func redactBody(body []byte) []byte {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+
+	var v interface{}
+
+	if err := dec.Decode(&v); err != nil || !redactValue(v) {
+		return body
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+
+	if err := enc.Encode(v); err != nil {
+		return body
+	}
+
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n"))
+}
+
+// redactValue redacts string values of sensitive fields in decoded JSON `v`, returns true if any is redacted
+func redactValue(v interface{}) bool {
+	found := false
+
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for k, item := range val {
+			if s, ok := item.(string); ok && s != "" && go_vkapi.IsSensitiveParam(k) {
+				val[k] = redacted
+				found = true
+			} else if redactValue(item) {
+				found = true
+			}
+		}
+	case []interface{}:
+		for _, item := range val {
+			if redactValue(item) {
+				found = true
+			}
+		}
+	}
+
+	return found
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2019 Konstantin Vasilev (burmuley@gmail.com)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vkapitest
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Burmuley/go-vkapi"
+)
+
+func TestCassette(t *testing.T) {
+	fName := filepath.Join(t.TempDir(), "cassette.json")
+	params := func() map[string]interface{} {
+		return map[string]interface{}{"user_id": 1, "count": 10, "offset": 0, "fields": "sex,city", "order": "name"}
+	}
+
+	// record interactions with the fake server
+	srv := NewServer()
+	srv.Respond("friends.get", map[string]interface{}{"count": 2, "items": []int{5, 6}})
+
+	rec, err := NewCassette(fName, ModeRecord)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	api := go_vkapi.NewApiWithToken("secret-token", go_vkapi.WithApiUrl(srv.ApiUrl()), go_vkapi.WithHTTPClient(rec.Client()))
+
+	if _, err := api.SendAPIRequest("friends.get", params()); err != nil {
+		t.Fatal(err)
+	}
+
+	// scripts of `execute` are told apart, tokens in responses are redacted
+	for _, v := range []string{"first", "second"} {
+		srv.Respond("execute", `{"script":"`+v+`","access_token":"body-token"}`)
+
+		if _, err := api.SendAPIRequest("execute", map[string]interface{}{"code": "return \"" + v + "\";"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	srv.Close()
+
+	b, err := os.ReadFile(fName)
+
+	if err != nil || !strings.Contains(string(b), "friends.get") {
+		t.Fatalf("recorded cassette = %s, %v, want friends.get interaction", b, err)
+	}
+
+	for _, v := range []string{"secret-token", "body-token", "return"} {
+		if strings.Contains(string(b), v) {
+			t.Fatalf("recorded cassette = %s, contains sensitive value %s", b, v)
+		}
+	}
+
+	// replay interactions without the server
+	play, err := NewCassette(fName, ModeReplay)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	api = go_vkapi.NewApiWithToken("another-token", go_vkapi.WithApiUrl(srv.ApiUrl()), go_vkapi.WithHTTPClient(play.Client()))
+
+	tests := []struct {
+		name    string
+		method  string
+		params  map[string]interface{}
+		want    string
+		wantErr bool
+	}{
+		{"TestReplay", "friends.get", params(), `{"count":2,"items":[5,6]}`, false},
+		{"TestExecuteScript", "execute", map[string]interface{}{"code": `return "second";`}, `{"access_token":"[REDACTED]","script":"second"}`, false},
+		{"TestUnmatchedScript", "execute", map[string]interface{}{"code": `return "third";`}, "", true},
+		{"TestReplayedOnce", "friends.get", params(), "", true},
+		{"TestUnmatchedParams", "friends.get", map[string]interface{}{"user_id": 2}, "", true},
+		{"TestUnmatchedMethod", "users.get", params(), "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := api.SendAPIRequest(tt.method, tt.params)
+
+			if (err != nil) != tt.wantErr || string(got) != tt.want {
+				t.Errorf("SendAPIRequest() = %s, %v, want %s, wantErr %v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
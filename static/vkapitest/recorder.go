@@ -0,0 +1,71 @@
+/*
+Copyright 2019 Konstantin Vasilev (burmuley@gmail.com)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vkapitest
+
+import "sync"
+
+// FakeCall is a call of a fake API method
+type FakeCall struct {
+	Method string        // Go method name, e.g. `GetExtended`
+	Args   []interface{} // method arguments except context
+}
+
+// Recorder records calls of fake API methods, it's embedded into every generated fake
+type Recorder struct {
+	mu    sync.Mutex
+	calls []FakeCall
+}
+
+// record saves a call of `method` with `args`
+func (r *Recorder) record(method string, args ...interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.calls = append(r.calls, FakeCall{Method: method, Args: args})
+}
+
+// Calls returns all recorded calls in order they were made
+func (r *Recorder) Calls() []FakeCall {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]FakeCall(nil), r.calls...)
+}
+
+// CallsOf returns recorded calls of `method` in order they were made
+func (r *Recorder) CallsOf(method string) []FakeCall {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	calls := make([]FakeCall, 0)
+
+	for _, c := range r.calls {
+		if c.Method == method {
+			calls = append(calls, c)
+		}
+	}
+
+	return calls
+}
+
+// Reset forgets all recorded calls
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.calls = nil
+}
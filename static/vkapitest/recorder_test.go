@@ -0,0 +1,59 @@
+/*
+Copyright 2019 Konstantin Vasilev (burmuley@gmail.com)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vkapitest
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRecorder(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		want   []FakeCall
+	}{
+		{"TestAll", "", []FakeCall{{"Get", []interface{}{1}}, {"Search", nil}, {"Get", []interface{}{2}}}},
+		{"TestOf", "Get", []FakeCall{{"Get", []interface{}{1}}, {"Get", []interface{}{2}}}},
+		{"TestNotCalled", "Delete", []FakeCall{}},
+	}
+
+	r := &Recorder{}
+	r.record("Get", 1)
+	r.record("Search")
+	r.record("Get", 2)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := r.Calls()
+
+			if tt.method != "" {
+				got = r.CallsOf(tt.method)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("calls = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	r.Reset()
+
+	if got := r.Calls(); len(got) != 0 {
+		t.Errorf("Calls() after Reset() = %v, want none", got)
+	}
+}
@@ -0,0 +1,243 @@
+/*
+Copyright 2019 Konstantin Vasilev (burmuley@gmail.com)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package vkapitest provides helpers to test code using VK API without network access
+package vkapitest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/Burmuley/go-vkapi"
+	"github.com/Burmuley/go-vkapi/errors"
+)
+
+// Error codes returned by Server
+const (
+	ErrCodeUnknownMethod = 3
+	ErrCodeInvalidParam  = 100
+)
+
+// paramSpec describes a method parameter
+type paramSpec struct {
+	Name      string
+	Type      string
+	ItemsType string
+	Required  bool
+}
+
+// methodSpec describes parameters and a sample response of a method
+type methodSpec struct {
+	Params   []paramSpec
+	Response string
+}
+
+// Call is an API method call received by Server
+type Call struct {
+	Method string
+	Params url.Values // request parameters without `access_token` and `v`
+}
+
+// Server is a fake VK API server for tests. It checks parameters of each call against methods schema and
+// returns a response synthesized from responses schema, unless a canned response or error is registered.
+type Server struct {
+	*httptest.Server
+
+	mu        sync.Mutex
+	responses map[string]json.RawMessage
+	errors    map[string]errors.ApiError
+	calls     []Call
+}
+
+// NewServer starts a new Server, it should be closed with `Close` at the end of the test
+func NewServer() *Server {
+	s := &Server{
+		responses: make(map[string]json.RawMessage),
+		errors:    make(map[string]errors.ApiError),
+	}
+	s.Server = httptest.NewServer(s)
+
+	return s
+}
+
+// ApiUrl returns base URL of API methods to pass to `go_vkapi.WithApiUrl`
+func (s *Server) ApiUrl() string {
+	return s.URL + "/method/"
+}
+
+// Api creates VKApi sending requests to the server
+func (s *Server) Api(opts ...go_vkapi.Option) *go_vkapi.VKApi {
+	return go_vkapi.NewApiWithToken("test-token", append([]go_vkapi.Option{go_vkapi.WithApiUrl(s.ApiUrl())}, opts...)...)
+}
+
+// Respond registers canned `response` of `method`. String, []byte and json.RawMessage are used as raw JSON,
+// other values are marshaled to JSON. Panics if `response` can't be marshaled.
+func (s *Server) Respond(method string, response interface{}) {
+	var raw json.RawMessage
+
+	switch v := response.(type) {
+	case string:
+		raw = json.RawMessage(v)
+	case []byte:
+		raw = json.RawMessage(v)
+	case json.RawMessage:
+		raw = v
+	default:
+		b, err := json.Marshal(v)
+
+		if err != nil {
+			panic(fmt.Sprintf("vkapitest: can't marshal response of `%s`: %s", method, err))
+		}
+
+		raw = b
+	}
+
+	s.mu.Lock()
+	s.responses[method] = raw
+	delete(s.errors, method)
+	s.mu.Unlock()
+}
+
+// Fail registers an error returned by `method`
+func (s *Server) Fail(method string, code int, message string) {
+	s.mu.Lock()
+	s.errors[method] = errors.ApiError{Code: code, Message: message}
+	delete(s.responses, method)
+	s.mu.Unlock()
+}
+
+// Calls returns all calls received by the server
+func (s *Server) Calls() []Call {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]Call(nil), s.calls...)
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if err := r.ParseForm(); err != nil {
+		writeError(w, errors.ApiError{Code: ErrCodeInvalidParam, Message: err.Error()})
+		return
+	}
+
+	method := strings.TrimPrefix(r.URL.Path, "/method/")
+	params := url.Values{}
+
+	for k, v := range r.Form {
+		if k != "access_token" && k != "v" {
+			params[k] = v
+		}
+	}
+
+	s.mu.Lock()
+	s.calls = append(s.calls, Call{Method: method, Params: params})
+	apiErr, failed := s.errors[method]
+	canned, hasCanned := s.responses[method]
+	s.mu.Unlock()
+
+	spec, ok := methodSpecs[method]
+
+	if !ok && !hasCanned && !failed {
+		writeError(w, errors.ApiError{Code: ErrCodeUnknownMethod, Message: "Unknown method passed"})
+		return
+	}
+
+	if r.Form.Get("access_token") == "" {
+		writeError(w, errors.ApiError{Code: errors.ErrCodeAuthFailed, Message: "User authorization failed: no access_token passed."})
+		return
+	}
+
+	if err := checkParams(spec.Params, params); err != nil {
+		writeError(w, *err)
+		return
+	}
+
+	if failed {
+		writeError(w, apiErr)
+		return
+	}
+
+	response := json.RawMessage(spec.Response)
+
+	if hasCanned {
+		response = canned
+	}
+
+	json.NewEncoder(w).Encode(struct {
+		Response json.RawMessage `json:"response"`
+	}{response})
+}
+
+// checkParams checks that all required parameters are passed and have values of expected types
+func checkParams(specs []paramSpec, params url.Values) *errors.ApiError {
+	for _, p := range specs {
+		value, ok := params[p.Name]
+
+		if !ok {
+			if p.Required {
+				return invalidParam(fmt.Sprintf("%s is a required parameter", p.Name))
+			}
+
+			continue
+		}
+
+		if p.Type == "array" {
+			for _, v := range strings.Split(value[0], ",") {
+				if !checkType(p.ItemsType, v) {
+					return invalidParam(fmt.Sprintf("%s not %s array", p.Name, p.ItemsType))
+				}
+			}
+		} else if !checkType(p.Type, value[0]) {
+			return invalidParam(fmt.Sprintf("%s not %s", p.Name, p.Type))
+		}
+	}
+
+	return nil
+}
+
+// checkType checks that `value` is a valid value of schema type `t`
+func checkType(t, value string) bool {
+	var err error
+
+	switch t {
+	case "integer":
+		_, err = strconv.ParseInt(value, 10, 64)
+	case "number":
+		_, err = strconv.ParseFloat(value, 64)
+	case "boolean":
+		_, err = strconv.ParseBool(value)
+	}
+
+	return err == nil
+}
+
+func invalidParam(msg string) *errors.ApiError {
+	return &errors.ApiError{Code: ErrCodeInvalidParam, Message: "One of the parameters specified was missing or invalid: " + msg}
+}
+
+func writeError(w http.ResponseWriter, err errors.ApiError) {
+	json.NewEncoder(w).Encode(struct {
+		Error errors.ApiError `json:"error"`
+	}{err})
+}
@@ -0,0 +1,122 @@
+/*
+Copyright 2019 Konstantin Vasilev (burmuley@gmail.com)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package vkapitest
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Burmuley/go-vkapi"
+	"github.com/Burmuley/go-vkapi/errors"
+	"github.com/Burmuley/go-vkapi/responses"
+)
+
+func TestServer(t *testing.T) {
+	sample := methodSpecs["friends.get"].Response
+
+	tests := []struct {
+		name     string
+		setup    func(s *Server)
+		method   string
+		params   map[string]interface{}
+		want     string
+		wantCode int
+	}{
+		{"TestSampleResponse", nil, "friends.get", map[string]interface{}{"count": 10}, sample, 0},
+		{
+			"TestCannedResponse",
+			func(s *Server) { s.Respond("friends.get", responses.FriendsGet{Count: 2, Items: []int{5, 6}}) },
+			"friends.get",
+			nil,
+			`{"count":2,"items":[5,6]}`,
+			0,
+		},
+		{
+			"TestCannedError",
+			func(s *Server) { s.Fail("friends.get", 15, "Access denied") },
+			"friends.get",
+			nil,
+			"",
+			15,
+		},
+		{"TestInvalidType", nil, "friends.get", map[string]interface{}{"count": "ten"}, "", ErrCodeInvalidParam},
+		{"TestArrayParam", nil, "friends.get", map[string]interface{}{"fields": "sex,city"}, sample, 0},
+		{"TestMissingRequired", nil, "docs.save", map[string]interface{}{"title": "doc"}, "", ErrCodeInvalidParam},
+		{"TestUnknownMethod", nil, "friends.unknown", nil, "", ErrCodeUnknownMethod},
+		{
+			"TestCannedUnknownMethod",
+			func(s *Server) { s.Respond("friends.unknown", `{"ok":1}`) },
+			"friends.unknown",
+			nil,
+			`{"ok":1}`,
+			0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewServer()
+			defer s.Close()
+
+			if tt.setup != nil {
+				tt.setup(s)
+			}
+
+			params := map[string]interface{}{}
+
+			for k, v := range tt.params {
+				params[k] = v
+			}
+
+			got, err := s.Api().SendAPIRequest(tt.method, params)
+
+			if tt.wantCode != 0 {
+				if apiErr, ok := err.(errors.ApiError); !ok || apiErr.Code != tt.wantCode {
+					t.Errorf("SendAPIRequest() error = %v, want code %d", err, tt.wantCode)
+				}
+
+				return
+			}
+
+			if err != nil || string(got) != tt.want {
+				t.Errorf("SendAPIRequest() = %s, %v, want %s", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestServer_Calls(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+
+	friends := go_vkapi.Friends{VKApi: s.Api()}
+
+	if _, err := friends.Get(1, "", 10, 0, []string{"sex", "city"}); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+
+	calls := s.Calls()
+
+	if len(calls) != 1 || calls[0].Method != "friends.get" {
+		t.Fatalf("Calls() = %v, want one `friends.get` call", calls)
+	}
+
+	want := map[string][]string{"user_id": {"1"}, "count": {"10"}, "fields": {"sex,city"}}
+
+	if !reflect.DeepEqual(map[string][]string(calls[0].Params), want) {
+		t.Errorf("call params = %v, want %v", calls[0].Params, want)
+	}
+}
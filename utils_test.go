@@ -176,3 +176,26 @@ func Test_readSchemaFile(t *testing.T) {
 		})
 	}
 }
+
+func Test_paramEncoder(t *testing.T) {
+	tests := []struct {
+		name  string
+		param schemaMethodItem
+		want  string
+	}{
+		{"TestInt", schemaMethodItem{Type: schemaTypeInt}, "EncodeInt"},
+		{"TestNumber", schemaMethodItem{Type: schemaTypeNumber}, "EncodeNumber"},
+		{"TestBool", schemaMethodItem{Type: schemaTypeBoolean}, "EncodeBool"},
+		{"TestString", schemaMethodItem{Type: schemaTypeString}, ""},
+		{"TestStrings", schemaMethodItem{Type: schemaTypeArray, Items: &schemaMethodItem{Type: schemaTypeString}}, "EncodeStrings"},
+		{"TestInts", schemaMethodItem{Type: schemaTypeArray, Items: &schemaMethodItem{Type: schemaTypeInt}}, "EncodeInts"},
+		{"TestRef", schemaMethodItem{Ref: "objects.json#/definitions/base_bool_int"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := paramEncoder(tt.param); got != tt.want {
+				t.Errorf("paramEncoder() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
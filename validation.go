@@ -0,0 +1,138 @@
+/*
+Copyright 2019 Konstantin Vasilev (burmuley@gmail.com)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"sort"
+)
+
+// Compact schema node embedded into the SDK to validate API responses.
+// Descriptions are dropped and references contain the schema file and definition name
+// (see `qualifyRef`): objects and responses can have the same names.
+type compactNode struct {
+	Type       []string                `json:"type,omitempty"`
+	Ref        string                  `json:"$ref,omitempty"`
+	Properties map[string]*compactNode `json:"properties,omitempty"`
+	Required   []string                `json:"required,omitempty"`
+	Items      *compactNode            `json:"items,omitempty"`
+	Enum       []interface{}           `json:"enum,omitempty"`
+	AllOf      []*compactNode          `json:"allOf,omitempty"`
+	OneOf      []*compactNode          `json:"oneOf,omitempty"`
+}
+
+// Response definitions references of a method
+type validationMethod struct {
+	Name        string
+	Response    string
+	ExtResponse string
+}
+
+// Data structure passed to the validation template
+type validationData struct {
+	Schema  string // compact JSON of objects and responses definitions by their references
+	Methods []validationMethod
+}
+
+// compactProperty: converts `p` to a compact schema node.
+// Tuple arrays items aren't validated and are omitted.
+func compactProperty(p *schemaJSONProperty) *compactNode {
+	if p == nil {
+		return nil
+	}
+
+	n := &compactNode{Required: p.Required, Enum: p.Enum}
+
+	if len(p.Type.Types) > 0 {
+		n.Type = p.Type.Types
+	} else if len(p.Type.Type) > 0 {
+		n.Type = []string{p.Type.Type}
+	}
+
+	if len(p.Ref) > 0 {
+		n.Ref = qualifyRef(p.Ref)
+	}
+
+	// properties of `allOf`/`oneOf` definitions are filled from their parts to render Go types, skip them
+	if len(p.Properties) > 0 && len(p.AllOf) == 0 && len(p.OneOf) == 0 {
+		n.Properties = make(map[string]*compactNode, len(p.Properties))
+
+		for k, v := range p.Properties {
+			n.Properties[k] = compactProperty(v)
+		}
+	}
+
+	if p.Items != nil {
+		n.Items = compactProperty(p.Items.Items)
+	}
+
+	for _, v := range p.AllOf {
+		n.AllOf = append(n.AllOf, compactProperty(v))
+	}
+
+	for _, v := range p.OneOf {
+		n.OneOf = append(n.OneOf, compactProperty(v))
+	}
+
+	return n
+}
+
+// buildValidation: builds compact schema of objects and responses definitions
+// and response definitions references of `methods`
+func buildValidation(methods []schemaMethod) (validationData, error) {
+	data := validationData{}
+	defs := make(map[string]*compactNode)
+
+	if objectsGlobal != nil {
+		for k := range objectsGlobal.Definitions {
+			def := objectsGlobal.Definitions[k]
+			defs[objectsRefPrefix+k] = compactProperty(&def)
+		}
+	}
+
+	if responsesGlobal != nil {
+		for k, v := range responsesGlobal.Definitions {
+			defs[responsesRefPrefix+k] = compactProperty(v.Properties["response"])
+		}
+	}
+
+	b, err := json.Marshal(defs)
+
+	if err != nil {
+		return data, err
+	}
+
+	data.Schema = string(b)
+
+	for _, m := range methods {
+		vm := validationMethod{Name: m.Name}
+
+		if m.Responses.Response != nil {
+			vm.Response = qualifyRef(m.Responses.Response.Ref)
+		}
+
+		if m.Responses.ExtResponse != nil {
+			vm.ExtResponse = qualifyRef(m.Responses.ExtResponse.Ref)
+		}
+
+		data.Methods = append(data.Methods, vm)
+	}
+
+	sort.Slice(data.Methods, func(i, j int) bool { return data.Methods[i].Name < data.Methods[j].Name })
+
+	return data, nil
+}
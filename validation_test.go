@@ -0,0 +1,107 @@
+/*
+Copyright 2019 Konstantin Vasilev (burmuley@gmail.com)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func Test_compactProperty(t *testing.T) {
+	tests := []struct {
+		name     string
+		property string
+		want     string
+	}{
+		{"TestSimple", `{"type": "integer", "description": "User ID", "enum": [0, 1]}`, `{"type":["integer"],"enum":[0,1]}`},
+		{"TestMultipleTypes", `{"type": ["integer", "string"]}`, `{"type":["integer","string"]}`},
+		{"TestRef", `{"$ref": "objects.json#/definitions/users_user"}`, `{"$ref":"objects.json#/definitions/users_user"}`},
+		{
+			"TestObject",
+			`{"type": "object", "required": ["id"], "properties": {"id": {"type": "integer"}, "ids": {"type": "array", "items": {"type": "integer"}}}}`,
+			`{"type":["object"],"properties":{"id":{"type":["integer"]},"ids":{"type":["array"],"items":{"type":["integer"]}}},"required":["id"]}`,
+		},
+		{"TestTuple", `{"type": "array", "items": [{"type": "integer"}, {"type": "string"}]}`, `{"type":["array"]}`},
+		{
+			"TestAllOfFilledProperties",
+			`{"allOf": [{"$ref": "#/definitions/users_user_min"}], "properties": {"id": {"type": "integer"}}}`,
+			`{"allOf":[{"$ref":"objects.json#/definitions/users_user_min"}]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &schemaJSONProperty{}
+
+			if err := json.Unmarshal([]byte(tt.property), p); err != nil {
+				t.Fatal(err)
+			}
+
+			b, err := json.Marshal(compactProperty(p))
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(b) != tt.want {
+				t.Errorf("compactProperty() = %s, want %s", b, tt.want)
+			}
+		})
+	}
+}
+
+func Test_buildValidation(t *testing.T) {
+	objects := `{"definitions": {"base_ok_response": {"type": "integer", "enum": [1]}}}`
+	responses := `{"definitions": {
+		"base_ok_response": {"type": "object", "properties": {"response": {"$ref": "objects.json#/definitions/base_ok_response"}}}
+	}}`
+
+	objectsGlobal, responsesGlobal = &objectsSchema{}, &responsesSchema{}
+
+	if err := json.Unmarshal([]byte(objects), objectsGlobal); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := json.Unmarshal([]byte(responses), responsesGlobal); err != nil {
+		t.Fatal(err)
+	}
+
+	defer func() { objectsGlobal, responsesGlobal = nil, nil }()
+
+	m := schemaMethod{Name: "account.setOnline"}
+	m.Responses.Response = &schemaMethodItem{Ref: "responses.json#/definitions/base_ok_response"}
+
+	got, err := buildValidation([]schemaMethod{m})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// response and object with the same name are kept apart, the response references the object
+	wantSchema := `{"objects.json#/definitions/base_ok_response":{"type":["integer"],"enum":[1]},` +
+		`"responses.json#/definitions/base_ok_response":{"$ref":"objects.json#/definitions/base_ok_response"}}`
+	wantMethods := []validationMethod{{Name: "account.setOnline", Response: "responses.json#/definitions/base_ok_response"}}
+
+	if got.Schema != wantSchema {
+		t.Errorf("buildValidation() schema = %s, want %s", got.Schema, wantSchema)
+	}
+
+	if !reflect.DeepEqual(got.Methods, wantMethods) {
+		t.Errorf("buildValidation() methods = %v, want %v", got.Methods, wantMethods)
+	}
+}